@@ -0,0 +1,297 @@
+// Package api builds a canonical snapshot of the exported API of packages and
+// classifies the differences between two snapshots as compatible or breaking.
+//
+// A snapshot is a sorted list of lines, one per exported declaration or
+// member, in the spirit of the api files of the Go distribution:
+//
+//	pkg example.com/mod/p, const Max untyped int = 10
+//	pkg example.com/mod/p, func New(string) (*Client, error)
+//	pkg example.com/mod/p, method (*Client) Close() error
+//	pkg example.com/mod/p, type Client struct
+//	pkg example.com/mod/p, type Client struct, Timeout time.Duration
+//	pkg example.com/mod/p, type Doer interface
+//	pkg example.com/mod/p, type Doer interface, Do(context.Context) error
+package api
+
+import (
+	"bufio"
+	"fmt"
+	"go/token"
+	"go/types"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/chavacava/gusano/lint"
+)
+
+// Decl is an exported declaration, or a member of an exported type.
+type Decl struct {
+	// Package is the path of the package of the declaration
+	Package string
+	// Text is the canonical description of the declaration, without the package
+	Text string
+	// Position is where the declaration is, in the source or in the snapshot
+	Position token.Position
+}
+
+// Line returns the line of the declaration in a snapshot.
+func (d Decl) Line() string {
+	return "pkg " + d.Package + ", " + d.Text
+}
+
+// key identifies the declaration across snapshots: two declarations with the
+// same key and different texts are the same declaration changed.
+func (d Decl) key() string {
+	return d.Package + ", " + declKey(d.Text)
+}
+
+// Dump returns the exported declarations of the package, sorted.
+func Dump(pkg *lint.Package) []Decl {
+	if pkg.TypesPkg == nil {
+		return nil
+	}
+	d := &dumper{pkg: pkg, qualifier: types.RelativeTo(pkg.TypesPkg)}
+	scope := pkg.TypesPkg.Scope()
+	for _, name := range scope.Names() {
+		obj := scope.Lookup(name)
+		if !obj.Exported() {
+			continue
+		}
+		switch obj := obj.(type) {
+		case *types.Const:
+			d.add(obj, fmt.Sprintf("const %s %s = %s", name, d.typeString(obj.Type()), obj.Val().ExactString()))
+		case *types.Var:
+			d.add(obj, fmt.Sprintf("var %s %s", name, d.typeString(obj.Type())))
+		case *types.Func:
+			d.add(obj, "func "+name+d.signature(obj.Type().(*types.Signature)))
+		case *types.TypeName:
+			d.typeName(obj)
+		}
+	}
+	sort.Slice(d.decls, func(i, j int) bool { return d.decls[i].Text < d.decls[j].Text })
+	return d.decls
+}
+
+type dumper struct {
+	pkg       *lint.Package
+	qualifier types.Qualifier
+	decls     []Decl
+}
+
+func (d *dumper) add(obj types.Object, text string) {
+	d.decls = append(d.decls, Decl{Package: d.pkg.TypesPkg.Path(), Text: text, Position: d.pkg.Fset().Position(obj.Pos())})
+}
+
+func (d *dumper) typeString(t types.Type) string {
+	return types.TypeString(t, d.qualifier)
+}
+
+// typeName adds the declaration of the type, of its exported fields or
+// interface methods, and of its exported methods.
+func (d *dumper) typeName(tn *types.TypeName) {
+	name := tn.Name()
+	if tn.IsAlias() {
+		d.add(tn, fmt.Sprintf("type %s = %s", name, d.typeString(tn.Type())))
+		return
+	}
+	named, ok := tn.Type().(*types.Named)
+	if !ok {
+		return
+	}
+	header := "type " + name + d.typeParams(named.TypeParams())
+
+	switch u := named.Underlying().(type) {
+	case *types.Struct:
+		d.add(tn, header+" struct")
+		for i := 0; i < u.NumFields(); i++ {
+			f := u.Field(i)
+			switch {
+			case f.Embedded():
+				d.add(f, fmt.Sprintf("type %s struct, embedded %s", name, d.typeString(f.Type())))
+			case f.Exported():
+				d.add(f, fmt.Sprintf("type %s struct, %s %s", name, f.Name(), d.typeString(f.Type())))
+			}
+		}
+	case *types.Interface:
+		d.add(tn, header+" interface")
+		sealed := false
+		for i := 0; i < u.NumMethods(); i++ {
+			m := u.Method(i)
+			if !m.Exported() {
+				sealed = true
+				continue
+			}
+			d.add(m, fmt.Sprintf("type %s interface, %s%s", name, m.Name(), d.signature(m.Type().(*types.Signature))))
+		}
+		if sealed {
+			d.add(tn, fmt.Sprintf("type %s interface, unexported methods", name))
+		}
+	default:
+		d.add(tn, header+" "+d.typeString(u))
+	}
+
+	recvName := name
+	if tparams := named.TypeParams(); tparams.Len() > 0 {
+		names := make([]string, tparams.Len())
+		for i := range names {
+			names[i] = tparams.At(i).Obj().Name()
+		}
+		recvName += "[" + strings.Join(names, ", ") + "]"
+	}
+	for i := 0; i < named.NumMethods(); i++ {
+		m := named.Method(i)
+		if !m.Exported() {
+			continue
+		}
+		recv := recvName
+		if _, ok := m.Type().(*types.Signature).Recv().Type().(*types.Pointer); ok {
+			recv = "*" + recv
+		}
+		d.add(m, fmt.Sprintf("method (%s) %s%s", recv, m.Name(), d.signature(m.Type().(*types.Signature))))
+	}
+}
+
+// signature returns the signature of a function without the names of its
+// parameters and results, which are not part of the API.
+func (d *dumper) signature(sig *types.Signature) string {
+	b := &strings.Builder{}
+	b.WriteString(d.typeParams(sig.TypeParams()))
+	b.WriteString("(")
+	params := sig.Params()
+	for i := 0; i < params.Len(); i++ {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		t := params.At(i).Type()
+		if sig.Variadic() && i == params.Len()-1 {
+			if s, ok := t.(*types.Slice); ok {
+				b.WriteString("..." + d.typeString(s.Elem()))
+				continue
+			}
+		}
+		b.WriteString(d.typeString(t))
+	}
+	b.WriteString(")")
+
+	results := sig.Results()
+	switch results.Len() {
+	case 0:
+	case 1:
+		b.WriteString(" " + d.typeString(results.At(0).Type()))
+	default:
+		list := make([]string, results.Len())
+		for i := range list {
+			list[i] = d.typeString(results.At(i).Type())
+		}
+		b.WriteString(" (" + strings.Join(list, ", ") + ")")
+	}
+	return b.String()
+}
+
+func (d *dumper) typeParams(tparams *types.TypeParamList) string {
+	if tparams.Len() == 0 {
+		return ""
+	}
+	list := make([]string, tparams.Len())
+	for i := range list {
+		tp := tparams.At(i)
+		list[i] = tp.Obj().Name() + " " + d.typeString(tp.Constraint())
+	}
+	return "[" + strings.Join(list, ", ") + "]"
+}
+
+// Write writes the declarations to w, one per line, sorted by package.
+func Write(w io.Writer, decls []Decl) error {
+	lines := make([]string, len(decls))
+	for i, d := range decls {
+		lines[i] = d.Line()
+	}
+	sort.Strings(lines)
+	b := &strings.Builder{}
+	for _, line := range lines {
+		b.WriteString(line + "\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Read reads the declarations of a snapshot written by Write. Blank lines
+// and lines starting with # are ignored.
+func Read(r io.Reader, filename string) ([]Decl, error) {
+	result := []Decl{}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rest := strings.TrimPrefix(line, "pkg ")
+		comma := strings.Index(rest, ", ")
+		if rest == line || comma < 0 {
+			return nil, fmt.Errorf("%s:%d: invalid API line %q, expected pkg <path>, <declaration>", filename, n, line)
+		}
+		result = append(result, Decl{
+			Package:  rest[:comma],
+			Text:     rest[comma+2:],
+			Position: token.Position{Filename: filename, Line: n, Column: 1},
+		})
+	}
+	return result, scanner.Err()
+}
+
+// declKey returns the part of the text of a declaration that identifies it:
+// its kind and name, the type and name for methods and members.
+func declKey(text string) string {
+	kind, rest := text, ""
+	if i := strings.Index(text, " "); i >= 0 {
+		kind, rest = text[:i], text[i+1:]
+	}
+	switch kind {
+	case "method":
+		// method (*T[K]) M(...) is identified by T.M: moving a method
+		// between the value and the pointer receiver changes it
+		end := strings.Index(rest, ") ")
+		if end < 0 {
+			return text
+		}
+		recv := strings.TrimPrefix(strings.TrimPrefix(rest[:end], "("), "*")
+		return kind + " " + leadingIdent(recv) + "." + leadingIdent(rest[end+2:])
+	case "type":
+		if member := memberOf(rest); member != "" {
+			if strings.HasPrefix(member, "embedded ") {
+				return text
+			}
+			return kind + " " + leadingIdent(rest) + " " + leadingIdent(member)
+		}
+	}
+	return kind + " " + leadingIdent(rest)
+}
+
+// memberOf returns the member part of the description of a type, after the
+// first ", " that is not within brackets (e.g. "F int" in "T struct, F int").
+func memberOf(text string) string {
+	depth := 0
+	for i, c := range text {
+		switch c {
+		case '[', '(', '{':
+			depth++
+		case ']', ')', '}':
+			depth--
+		case ',':
+			if depth == 0 && strings.HasPrefix(text[i:], ", ") {
+				return text[i+2:]
+			}
+		}
+	}
+	return ""
+}
+
+func leadingIdent(s string) string {
+	for i, c := range s {
+		if !(c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c > 127) {
+			return s[:i]
+		}
+	}
+	return s
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"sort"
+	"strings"
+)
+
+// ChangeKind is the kind of a difference between two snapshots.
+type ChangeKind string
+
+// Kinds of changes.
+const (
+	Added   ChangeKind = "added"
+	Removed ChangeKind = "removed"
+	Changed ChangeKind = "changed"
+)
+
+// Change is a difference between two snapshots of an API.
+type Change struct {
+	Kind ChangeKind
+	// Old and New are the declaration before and after the change; Old is
+	// nil for additions, New is nil for removals
+	Old, New *Decl
+	// Breaking is true if code using the old API may not compile with the new one
+	Breaking bool
+	// Reason explains why an addition is breaking
+	Reason string
+}
+
+// Package returns the path of the package of the changed declaration.
+func (c Change) Package() string {
+	if c.New != nil {
+		return c.New.Package
+	}
+	return c.Old.Package
+}
+
+// Compare returns the changes from the old declarations to the new ones,
+// sorted by package and declaration.
+// Additions are compatible, except the methods added to interfaces that can
+// be implemented outside of their package. Removals and changes are breaking,
+// except moving a method from the pointer to the value receiver, which only
+// enlarges the method set of the type, and making an interface implementable.
+func Compare(old, new []Decl) []Change {
+	oldByKey := byKey(old)
+	newByKey := byKey(new)
+	oldLines := map[string]bool{}
+	for _, d := range old {
+		oldLines[d.Line()] = true
+	}
+
+	result := []Change{}
+	for key, o := range oldByKey {
+		n, ok := newByKey[key]
+		switch {
+		case !ok:
+			breaking := !strings.HasSuffix(o.Text, " interface, unexported methods")
+			result = append(result, Change{Kind: Removed, Old: o, Breaking: breaking})
+		case n.Text != o.Text:
+			breaking := strings.Replace(o.Text, "method (*", "method (", 1) != n.Text
+			result = append(result, Change{Kind: Changed, Old: o, New: n, Breaking: breaking})
+		}
+	}
+	for key, n := range newByKey {
+		if _, ok := oldByKey[key]; ok {
+			continue
+		}
+		c := Change{Kind: Added, New: n}
+		if iface, ok := interfaceOf(n.Text); ok {
+			header := "pkg " + n.Package + ", type " + iface + " interface"
+			sealed := oldLines["pkg "+n.Package+", type "+iface+" interface, unexported methods"]
+			if oldHasHeader(oldLines, header) && !sealed {
+				c.Breaking = true
+				c.Reason = "the types implementing " + iface + " outside of its package no longer implement it"
+			}
+		}
+		result = append(result, c)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Package() != result[j].Package() {
+			return result[i].Package() < result[j].Package()
+		}
+		return result[i].text() < result[j].text()
+	})
+	return result
+}
+
+func (c Change) text() string {
+	if c.Old != nil {
+		return c.Old.Text
+	}
+	return c.New.Text
+}
+
+func byKey(decls []Decl) map[string]*Decl {
+	result := map[string]*Decl{}
+	for i := range decls {
+		result[decls[i].key()] = &decls[i]
+	}
+	return result
+}
+
+// interfaceOf returns the name of the interface of which the declaration is
+// a method, if any.
+func interfaceOf(text string) (string, bool) {
+	if !strings.HasPrefix(text, "type ") {
+		return "", false
+	}
+	member := memberOf(text[len("type "):])
+	if member == "" || member == "unexported methods" {
+		return "", false
+	}
+	name := leadingIdent(text[len("type "):])
+	if !strings.HasPrefix(text, "type "+name+" interface, ") {
+		return "", false
+	}
+	return name, true
+}
+
+// oldHasHeader returns true if the interface was declared in the old API,
+// possibly with type parameters.
+func oldHasHeader(lines map[string]bool, header string) bool {
+	if lines[header] {
+		return true
+	}
+	prefix := strings.TrimSuffix(header, " interface") + "["
+	for line := range lines {
+		if strings.HasPrefix(line, prefix) && strings.HasSuffix(line, " interface") {
+			return true
+		}
+	}
+	return false
+}
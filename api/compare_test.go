@@ -0,0 +1,113 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func decls(pkg string, texts ...string) []Decl {
+	result := []Decl{}
+	for _, text := range texts {
+		result = append(result, Decl{Package: pkg, Text: text})
+	}
+	return result
+}
+
+func TestCompare(t *testing.T) {
+	const p = "example.com/mod/p"
+	tests := []struct {
+		name     string
+		old, new []string
+		want     []string
+	}{
+		{
+			name: "unchanged",
+			old:  []string{"func New() *Client", "type Client struct"},
+			new:  []string{"type Client struct", "func New() *Client"},
+			want: []string{},
+		},
+		{
+			name: "removed",
+			old:  []string{"func New() *Client", "const Max untyped int = 10"},
+			new:  []string{"func New() *Client"},
+			want: []string{"removed breaking: const Max untyped int = 10"},
+		},
+		{
+			name: "changed",
+			old:  []string{"func New() *Client", "const Max untyped int = 10", "type Client struct, Timeout int"},
+			new:  []string{"func New(string) *Client", "const Max untyped int = 20", "type Client struct, Timeout int64"},
+			want: []string{
+				"changed breaking: const Max untyped int = 10 -> const Max untyped int = 20",
+				"changed breaking: func New() *Client -> func New(string) *Client",
+				"changed breaking: type Client struct, Timeout int -> type Client struct, Timeout int64",
+			},
+		},
+		{
+			name: "receivers",
+			old:  []string{"method (*Client) Close() error", "method (Client) Name() string"},
+			new:  []string{"method (Client) Close() error", "method (*Client) Name() string"},
+			want: []string{
+				"changed compatible: method (*Client) Close() error -> method (Client) Close() error",
+				"changed breaking: method (Client) Name() string -> method (*Client) Name() string",
+			},
+		},
+		{
+			name: "added",
+			old:  []string{"type Doer interface", "type Sealed interface", "type Sealed interface, unexported methods", "type List[T any] interface"},
+			new: []string{
+				"func New() *Client",
+				"type Doer interface", "type Doer interface, Do() error",
+				"type Sealed interface", "type Sealed interface, unexported methods", "type Sealed interface, Do() error",
+				"type List[T any] interface", "type List interface, Len() int",
+				"type Fresh interface", "type Fresh interface, Do() error",
+			},
+			want: []string{
+				"added compatible: func New() *Client",
+				"added breaking: type Doer interface, Do() error (the types implementing Doer outside of its package no longer implement it)",
+				"added compatible: type Fresh interface",
+				"added compatible: type Fresh interface, Do() error",
+				"added breaking: type List interface, Len() int (the types implementing List outside of its package no longer implement it)",
+				"added compatible: type Sealed interface, Do() error",
+			},
+		},
+		{
+			name: "unsealed",
+			old:  []string{"type Doer interface", "type Doer interface, unexported methods"},
+			new:  []string{"type Doer interface"},
+			want: []string{"removed compatible: type Doer interface, unexported methods"},
+		},
+	}
+	for _, test := range tests {
+		got := []string{}
+		for _, c := range Compare(decls(p, test.old...), decls(p, test.new...)) {
+			kind := "compatible"
+			if c.Breaking {
+				kind = "breaking"
+			}
+			text := string(c.Kind) + " " + kind + ": "
+			switch c.Kind {
+			case Added:
+				text += c.New.Text
+			case Removed:
+				text += c.Old.Text
+			case Changed:
+				text += c.Old.Text + " -> " + c.New.Text
+			}
+			if c.Reason != "" {
+				text += " (" + c.Reason + ")"
+			}
+			got = append(got, text)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: Compare() =\n%q\nwant\n%q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestCompareAcrossPackages(t *testing.T) {
+	old := append(decls("example.com/mod/b", "func B()"), decls("example.com/mod/a", "func A()")...)
+	changes := Compare(old, nil)
+	if len(changes) != 2 || changes[0].Package() != "example.com/mod/a" || changes[1].Package() != "example.com/mod/b" {
+		t.Errorf("Compare() = %v, want the removals of a.A and b.B", changes)
+	}
+}
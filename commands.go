@@ -0,0 +1,286 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/chavacava/gusano/api"
+	"github.com/chavacava/gusano/depgraph"
+	"github.com/chavacava/gusano/lint"
+	"github.com/chavacava/gusano/usage"
+	"golang.org/x/tools/go/packages"
+)
+
+// command is a subcommand of gusano, run instead of linting when its name is
+// the first argument (e.g. gusano graph ./...).
+type command struct {
+	usage string
+	run   func(config *lint.Config, args []string)
+}
+
+var commands = map[string]command{
+	"graph":     {"export the import graph of the packages", runGraph},
+	"who-uses":  {"list the references to an exported symbol (e.g. who-uses example.com/mod/pkg.Func ./...)", runWhoUses},
+	"api-usage": {"list the exported symbols of a package and their references from other packages", runAPIUsage},
+	"api":       {"dump the exported API of the packages, or check it against a snapshot (api dump|check)", runAPI},
+}
+
+// commandsUsage describes the commands for the help message.
+func commandsUsage() string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	b := &strings.Builder{}
+	b.WriteString("Commands (gusano [flags] <command> -h for help):\n")
+	for _, name := range names {
+		fmt.Fprintf(b, "  %s\t%s\n", name, commands[name].usage)
+	}
+	return b.String()
+}
+
+// newCommandFlags returns the flag set of a command.
+func newCommandFlags(name, args string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: gusano [flags] %s [%s flags] %s\n", name, name, args)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// commandOutput returns the writer of the output of commands: the -output file or the standard output.
+func commandOutput() (io.Writer, func()) {
+	if outputPath == "" {
+		return os.Stdout, func() {}
+	}
+	out, err := os.Create(outputPath)
+	if err != nil {
+		fail("cannot create the output file: " + err.Error())
+	}
+	return out, func() {
+		if err := out.Close(); err != nil {
+			fail("cannot write the output file: " + err.Error())
+		}
+	}
+}
+
+func runGraph(config *lint.Config, args []string) {
+	flags := newCommandFlags("graph", "[packages]")
+	format := flags.String("format", "dot", "output format: "+strings.Join(depgraph.Formats, ", "))
+	level := flags.String("level", string(depgraph.LevelPackage), "granularity of the nodes: package, directory or module")
+	dirDepth := flags.Int("dir-depth", 1, "number of path elements below the module path identifying a directory, at directory level")
+	internal := flags.Bool("internal", false, "only include the packages of the modules of the loaded packages")
+	focus := flags.String("focus", "", "only include the nodes importing, or imported by, the node of the given package path")
+	depth := flags.Int("depth", -1, "maximum number of imports from the focus, or from the loaded packages (-1 for no limit)")
+	flags.Parse(args)
+
+	switch depgraph.Level(*level) {
+	case depgraph.LevelPackage, depgraph.LevelDirectory, depgraph.LevelModule:
+	default:
+		fail(fmt.Sprintf("unknown graph level %q, expected package, directory or module", *level))
+	}
+	if *dirDepth < 1 {
+		fail("the directory depth of the graph must be at least 1")
+	}
+
+	g := depgraph.Build(getPackages(config, flags.Args()), depgraph.Options{
+		Level:        depgraph.Level(*level),
+		DirDepth:     *dirDepth,
+		InternalOnly: *internal,
+		Focus:        *focus,
+		Depth:        *depth,
+	})
+
+	out, done := commandOutput()
+	if err := g.Write(out, *format); err != nil {
+		fail(err.Error())
+	}
+	done()
+}
+
+func runWhoUses(config *lint.Config, args []string) {
+	flags := newCommandFlags("who-uses", "<package path>.<Symbol> [packages]")
+	format := flags.String("format", "text", "output format: "+strings.Join(usage.Formats, ", "))
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	pkgs := getPackages(config, flags.Args()[1:])
+	pkgPath, name, err := usage.ParseSymbol(pkgs, flags.Arg(0))
+	if err != nil {
+		fail(err.Error())
+	}
+	symbol, err := usage.WhoUses(pkgs, pkgPath, name)
+	if err != nil {
+		fail(err.Error())
+	}
+
+	out, done := commandOutput()
+	if err := symbol.Write(out, pkgPath, *format); err != nil {
+		fail(err.Error())
+	}
+	done()
+}
+
+func runAPIUsage(config *lint.Config, args []string) {
+	flags := newCommandFlags("api-usage", "<package path> [packages]")
+	format := flags.String("format", "text", "output format: "+strings.Join(usage.Formats, ", "))
+	unused := flags.Bool("unused", false, "only list the symbols without references")
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	report, err := usage.APIUsage(getPackages(config, flags.Args()[1:]), flags.Arg(0))
+	if err != nil {
+		fail(err.Error())
+	}
+	if *unused {
+		report.Symbols = report.Unused()
+	}
+
+	out, done := commandOutput()
+	if err := report.Write(out, *format); err != nil {
+		fail(err.Error())
+	}
+	done()
+}
+
+func runAPI(config *lint.Config, args []string) {
+	if len(args) == 0 || (args[0] != "dump" && args[0] != "check") {
+		fail("Usage: gusano [flags] api dump|check [api flags] [packages]")
+	}
+	if args[0] == "dump" {
+		flags := newCommandFlags("api dump", "[packages]")
+		flags.Parse(args[1:])
+		out, done := commandOutput()
+		if err := api.Write(out, apiOf(getPackages(config, flags.Args()))); err != nil {
+			fail(err.Error())
+		}
+		done()
+		return
+	}
+
+	os.Exit(checkAPI(config, args[1:]))
+}
+
+// checkAPI runs the api check command and returns its exit code. Breaking
+// changes, reported as errors, lead to a failing exit code even without
+// -set-exit-status, unless the configuration sets another error code.
+func checkAPI(config *lint.Config, args []string) int {
+	flags := newCommandFlags("api check", "[packages]")
+	snapshot := flags.String("snapshot", "", "API snapshot written by api dump to compare with (e.g. -snapshot api.txt)")
+	against := flags.String("against", "", "directory of another checkout whose packages, matching the same patterns, give the API to compare with")
+	breakingOnly := flags.Bool("breaking", false, "only report breaking changes")
+	flags.Parse(args)
+	if (*snapshot == "") == (*against == "") {
+		fail("api check requires either -snapshot or -against")
+	}
+
+	var old []api.Decl
+	if *snapshot != "" {
+		file, err := os.Open(*snapshot)
+		if err != nil {
+			fail("cannot read the API snapshot: " + err.Error())
+		}
+		old, err = api.Read(file, *snapshot)
+		file.Close()
+		if err != nil {
+			fail(err.Error())
+		}
+	} else {
+		patterns := normalizeSplit(flags.Args())
+		if len(patterns) == 0 {
+			patterns = []string{"."}
+		}
+		pkgs, err := lint.LoadPackages(*against, patterns, *config)
+		if err != nil {
+			fail(err.Error())
+		}
+		old = apiOf(pkgs)
+	}
+	current := apiOf(getPackages(config, flags.Args()))
+
+	failures := make(chan lint.Failure)
+	go func() {
+		for _, c := range api.Compare(old, current) {
+			if c.Breaking || !*breakingOnly {
+				failures <- apiFailure(c)
+			}
+		}
+		close(failures)
+	}()
+
+	if config.ErrorCode == 0 {
+		config.ErrorCode = 1
+	}
+	formatter := getFormatter()
+	closeOutput := redirectOutput()
+	exitCode := reportFailures(config, formatter, failures)
+	closeOutput()
+	return exitCode
+}
+
+// apiOf returns the exported API of the packages, leaving out external test
+// packages and the declarations of test files, as packages loaded with tests
+// are only kept in their variant including the test files.
+// The APIs of the build configurations are merged.
+func apiOf(pkgs []*packages.Package) []api.Decl {
+	module, err := lint.NewModule(pkgs)
+	if err != nil {
+		fail(err.Error())
+	}
+	result := []api.Decl{}
+	seen := map[string]bool{}
+	for _, pkg := range module.Packages {
+		if pkg.TypesPkg == nil || strings.HasSuffix(pkg.TypesPkg.Path(), "_test") {
+			continue
+		}
+		for _, d := range api.Dump(pkg) {
+			if !seen[d.Line()] && !strings.HasSuffix(d.Position.Filename, "_test.go") {
+				seen[d.Line()] = true
+				result = append(result, d)
+			}
+		}
+	}
+	return result
+}
+
+// apiFailure returns the failure reporting an API change: at the new
+// declaration, or at the old one for removals.
+func apiFailure(c api.Change) lint.Failure {
+	kind, severity := "compatible", lint.Severity("")
+	if c.Breaking {
+		kind, severity = "breaking", lint.SeverityError
+	}
+	var msg string
+	position := c.Old
+	switch c.Kind {
+	case api.Added:
+		msg, position = "added "+c.New.Text, c.New
+	case api.Removed:
+		msg = "removed " + c.Old.Text
+	case api.Changed:
+		msg, position = c.Old.Text+" changed to "+c.New.Text, c.New
+	}
+	if c.Reason != "" {
+		msg += " (" + c.Reason + ")"
+	}
+	return lint.Failure{
+		RuleName:   "api",
+		Category:   "api",
+		Severity:   severity,
+		Confidence: 1,
+		Failure:    fmt.Sprintf("%s change in package %s: %s", kind, c.Package(), msg),
+		Position:   lint.FailurePosition{Start: position.Position, End: position.Position},
+	}
+}
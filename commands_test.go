@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chavacava/gusano/lint"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// runCommand runs the command with the given arguments and returns its
+// output, written to a temporary -output file, with the file paths made
+// relative to the working directory.
+func runCommand(t *testing.T, config *lint.Config, run func(*lint.Config, []string), args ...string) []byte {
+	t.Helper()
+	outputPath = filepath.Join(t.TempDir(), "output")
+	defer func() { outputPath = "" }()
+	run(config, args)
+	output, err := ioutil.ReadFile(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytes.ReplaceAll(output, []byte(wd+string(filepath.Separator)), nil)
+}
+
+// checkGolden compares got with the content of the golden file of the
+// commands test data, or updates the file with the -update flag.
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	golden := filepath.Join("testdata", "commands", name)
+	if *update {
+		if err := ioutil.WriteFile(golden, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output does not match %s; got:\n%s", golden, got)
+	}
+}
+
+func TestGraphCommand(t *testing.T) {
+	tests := []struct {
+		golden string
+		args   []string
+	}{
+		{"graph.dot", []string{"-internal", "./testdata/pkg14/..."}},
+		{"graph.json", []string{"-format", "json", "-internal", "./testdata/pkg14/..."}},
+		{"graph.mmd", []string{"-format", "mermaid", "-internal", "./testdata/pkg14/..."}},
+		{"graph-focus.mmd", []string{"-format", "mermaid", "-internal", "-focus", "github.com/chavacava/gusano/testdata/pkg14/app/events", "-depth", "1", "./testdata/pkg14/..."}},
+		{"graph-directories.dot", []string{"-level", "directory", "-dir-depth", "3", "-internal", "./testdata/pkg14/..."}},
+	}
+	for _, test := range tests {
+		checkGolden(t, test.golden, runCommand(t, &lint.Config{}, runGraph, test.args...))
+	}
+}
+
+func TestWhoUsesCommand(t *testing.T) {
+	checkGolden(t, "who-uses.txt", runCommand(t, &lint.Config{}, runWhoUses, "github.com/chavacava/gusano/testdata/pkg14/domain.Order", "./testdata/pkg14/..."))
+	checkGolden(t, "who-uses.json", runCommand(t, &lint.Config{}, runWhoUses, "-format", "json", "github.com/chavacava/gusano/testdata/pkg14/domain.Order.ID", "./testdata/pkg14/..."))
+}
+
+func TestAPIUsageCommand(t *testing.T) {
+	checkGolden(t, "api-usage.txt", runCommand(t, &lint.Config{}, runAPIUsage, "github.com/chavacava/gusano/testdata/pkg14/domain", "./testdata/pkg14/..."))
+	checkGolden(t, "api-usage-unused.txt", runCommand(t, &lint.Config{}, runAPIUsage, "-unused", "github.com/chavacava/gusano/testdata/pkg14/domain", "./testdata/pkg14/..."))
+}
+
+func TestAPIDumpCommand(t *testing.T) {
+	checkGolden(t, "api-dump.txt", runCommand(t, &lint.Config{}, runAPI, "dump", "./testdata/pkg9"))
+	// with tests, only the variant of the package including its test files
+	// is loaded, whose test files must be left out
+	checkGolden(t, "api-dump.txt", runCommand(t, &lint.Config{Tests: true}, runAPI, "dump", "./testdata/pkg9"))
+}
+
+func TestAPICheckCommand(t *testing.T) {
+	stdout := os.Stdout
+	defer func() { os.Stdout = stdout }()
+
+	var exitCode int
+	check := func(config *lint.Config, args []string) {
+		exitCode = checkAPI(config, args)
+	}
+	output := runCommand(t, &lint.Config{Confidence: 0.8}, check, "-snapshot", "testdata/commands/api-snapshot.txt", "./testdata/pkg9")
+	checkGolden(t, "api-check.txt", output)
+	if exitCode != 1 {
+		t.Errorf("api check exit code = %d, want 1 for breaking changes", exitCode)
+	}
+
+	output = runCommand(t, &lint.Config{Tests: true, Confidence: 0.8}, check, "-snapshot", "testdata/commands/api-dump.txt", "./testdata/pkg9")
+	if len(bytes.TrimSpace(output)) != 0 || exitCode != 0 {
+		t.Errorf("api check against the current API = %q with exit code %d, want no changes", output, exitCode)
+	}
+}
+
+func TestMetricsFlag(t *testing.T) {
+	write := func(config *lint.Config, args []string) {
+		metricsPath = outputPath
+		defer func() { metricsPath = "" }()
+		writeMetrics(getPackages(config, args))
+	}
+	checkGolden(t, "metrics.json", runCommand(t, &lint.Config{}, write, "./testdata/pkg15/..."))
+}
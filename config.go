@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/mitchellh/go-homedir"
@@ -15,8 +16,8 @@ import (
 	"github.com/BurntSushi/toml"
 	"github.com/chavacava/gusano/lint"
 	"github.com/chavacava/gusano/rule"
+	"github.com/olekukonko/tablewriter"
 	"golang.org/x/tools/go/packages"
-	gopack "golang.org/x/tools/go/packages"
 )
 
 func fail(err string) {
@@ -28,7 +29,19 @@ var defaultRules = []lint.Rule{
 	&rule.UnusedSymbolRule{},
 }
 
-var allRules = defaultRules
+var allRules = append([]lint.Rule{
+	&rule.DeadStoreRule{},
+	&rule.UnnecessaryExportRule{},
+	&rule.SimplifiableSignatureRule{},
+	&rule.LayeringRule{},
+	&rule.PackageMetricsRule{},
+	&rule.ImportCyclesRule{},
+	&rule.ForbiddenAPIRule{},
+	&rule.DuplicateCodeRule{},
+	&rule.BudgetsRule{},
+	&rule.UnhandledErrorRule{},
+	&rule.DeepExitRule{},
+}, defaultRules...)
 
 var allFormatters = []lint.Formatter{
 	&formatter.Stylish{},
@@ -67,47 +80,321 @@ func getLintingRules(config *lint.Config) []lint.Rule {
 	return lintingRules
 }
 
-func parseConfig(path string) *lint.Config {
-	config := &lint.Config{}
-	file, err := ioutil.ReadFile(path)
+// configOrigins maps each configuration key (e.g. "rule.unused-symbol.severity")
+// to the file or preset that set its effective value.
+type configOrigins map[string]string
+
+const defaultsOrigin = "(defaults)"
+
+// loadConfig reads the configuration referenced by ref, first applying the
+// configurations it extends, and merges it into config.
+// Relative references are resolved against baseDir.
+func loadConfig(ref, baseDir string, config *lint.Config, origins configOrigins, visiting map[string]bool) {
+	source, content := readConfigSource(ref, baseDir)
+	if visiting[source] {
+		fail("cyclic extends in the configuration: " + source)
+	}
+	visiting[source] = true
+	defer delete(visiting, source)
+
+	var header struct {
+		Extends []string `toml:"extends"`
+	}
+	if _, err := toml.Decode(content, &header); err != nil {
+		fail("cannot parse the config file " + source + ": " + err.Error())
+	}
+
+	dir := ""
+	if _, isPreset := presets[ref]; !isPreset {
+		dir = filepath.Dir(source)
+	}
+	for _, parent := range header.Extends {
+		loadConfig(parent, dir, config, origins, visiting)
+	}
+
+	layer := lint.Config{}
+	md, err := toml.Decode(content, &layer)
 	if err != nil {
-		fail("cannot read the config file")
+		fail("cannot parse the config file " + source + ": " + err.Error())
 	}
-	_, err = toml.Decode(string(file), config)
+	mergeConfig(config, &layer, md, source, origins)
+}
+
+// readConfigSource returns the name and the content of the configuration
+// referenced by ref, that is either the name of a built-in preset or a path.
+func readConfigSource(ref, baseDir string) (source, content string) {
+	if preset, ok := presets[ref]; ok {
+		return "preset:" + ref, preset
+	}
+
+	path, err := homedir.Expand(ref)
+	if err != nil {
+		fail("cannot resolve the config file " + ref + ": " + err.Error())
+	}
+	if !filepath.IsAbs(path) && baseDir != "" {
+		path = filepath.Join(baseDir, path)
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	file, err := ioutil.ReadFile(path)
 	if err != nil {
-		fail("cannot parse the config file: " + err.Error())
+		fail("cannot read the config file " + path)
+	}
+	return path, string(file)
+}
+
+// mergeConfig overrides values in dst with those explicitly defined in src,
+// recording source as their origin.
+func mergeConfig(dst, src *lint.Config, md toml.MetaData, source string, origins configOrigins) {
+	defined := map[string]bool{}
+	for _, k := range md.Keys() {
+		defined[strings.ToLower(k.String())] = true
+	}
+	set := func(key string) bool {
+		if !defined[strings.ToLower(key)] {
+			return false
+		}
+		origins[key] = source
+		return true
+	}
+
+	if set("ignoreGeneratedHeader") {
+		dst.IgnoreGeneratedHeader = src.IgnoreGeneratedHeader
+	}
+	if set("confidence") {
+		dst.Confidence = src.Confidence
+	}
+	if set("severity") {
+		dst.Severity = src.Severity
+	}
+	if set("errorCode") {
+		dst.ErrorCode = src.ErrorCode
+	}
+	if set("warningCode") {
+		dst.WarningCode = src.WarningCode
+	}
+	if set("exclude") {
+		dst.Exclude = src.Exclude
+	}
+	if set("buildTags") {
+		dst.BuildTags = src.BuildTags
+	}
+	if set("tests") {
+		dst.Tests = src.Tests
+	}
+	if set("build") {
+		dst.Builds = src.Builds
+	}
+
+	if dst.Rules == nil {
+		dst.Rules = lint.RulesConfig{}
+	}
+	for name, rc := range src.Rules {
+		key := "rule." + name
+		set(key)
+		current := dst.Rules[name]
+		if set(key + ".arguments") {
+			current.Arguments = rc.Arguments
+		}
+		if set(key + ".severity") {
+			current.Severity = rc.Severity
+		}
+		dst.Rules[name] = current
+	}
+
+	if dst.Directives == nil {
+		dst.Directives = lint.DirectivesConfig{}
+	}
+	for name, dc := range src.Directives {
+		key := "directive." + name
+		set(key)
+		current := dst.Directives[name]
+		if set(key + ".severity") {
+			current.Severity = dc.Severity
+		}
+		dst.Directives[name] = current
+	}
+}
+
+const commandLineOrigin = "(command line)"
+
+// applyFlags overrides the configuration with the values given in the command line.
+func applyFlags(config *lint.Config, origins configOrigins) {
+	setFlags := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) {
+		setFlags[f.Name] = true
+	})
+
+	if setFlags["confidence"] {
+		config.Confidence = confidence
+		origins["confidence"] = commandLineOrigin
 	}
-	return config
+
+	for _, name := range splitFlagList(enableRules) {
+		if _, ok := config.Rules[name]; !ok {
+			config.Rules[name] = lint.RuleConfig{}
+		}
+		origins["rule."+name] = commandLineOrigin
+	}
+	for _, name := range splitFlagList(disableRules) {
+		delete(config.Rules, name)
+	}
+
+	if setFlags["severity"] {
+		sev := lint.Severity(severityName)
+		if sev != lint.SeverityWarning && sev != lint.SeverityError {
+			fail("unknown severity " + severityName)
+		}
+		config.Severity = sev
+		origins["severity"] = commandLineOrigin
+		for name, rc := range config.Rules {
+			rc.Severity = sev
+			config.Rules[name] = rc
+			origins["rule."+name+".severity"] = commandLineOrigin
+		}
+		for name, dc := range config.Directives {
+			dc.Severity = sev
+			config.Directives[name] = dc
+			origins["directive."+name+".severity"] = commandLineOrigin
+		}
+	}
+
+	if setExitStatus {
+		if config.WarningCode == 0 {
+			config.WarningCode = 1
+			origins["warningCode"] = commandLineOrigin
+		}
+		if config.ErrorCode == 0 {
+			config.ErrorCode = 1
+			origins["errorCode"] = commandLineOrigin
+		}
+	}
+
+	if len(excludePaths) > 0 {
+		config.Exclude = append(config.Exclude, excludePaths...)
+		origins["exclude"] = commandLineOrigin
+	}
+	if setFlags["tags"] {
+		config.BuildTags = splitFlagList(buildTags)
+		origins["buildTags"] = commandLineOrigin
+	}
+	if setFlags["tests"] {
+		config.Tests = includeTests
+		origins["tests"] = commandLineOrigin
+	}
+}
+
+// splitFlagList splits comma or space separated values given in the command line.
+func splitFlagList(values []string) []string {
+	result := []string{}
+	for _, v := range values {
+		result = append(result, normalizeSplit(strings.FieldsFunc(v, func(r rune) bool {
+			return r == ',' || r == ' '
+		}))...)
+	}
+	return result
 }
 
-func normalizeConfig(config *lint.Config) {
-	if config.Confidence == 0 {
+func normalizeConfig(config *lint.Config, origins configOrigins) {
+	if _, set := origins["confidence"]; !set && config.Confidence == 0 {
 		config.Confidence = 0.8
+		origins["confidence"] = defaultsOrigin
 	}
 	severity := config.Severity
 	if severity != "" {
+		severityOrigin, ok := origins["severity"]
+		if !ok {
+			severityOrigin = defaultsOrigin
+		}
 		for k, v := range config.Rules {
 			if v.Severity == "" {
 				v.Severity = severity
+				origins["rule."+k+".severity"] = severityOrigin
 			}
 			config.Rules[k] = v
 		}
 		for k, v := range config.Directives {
 			if v.Severity == "" {
 				v.Severity = severity
+				origins["directive."+k+".severity"] = severityOrigin
 			}
 			config.Directives[k] = v
 		}
 	}
 }
 
-func getConfig() *lint.Config {
-	config := defaultConfig()
-	if configPath != "" {
-		config = parseConfig(configPath)
+func getConfig() (*lint.Config, configOrigins) {
+	origins := configOrigins{}
+	config := defaultConfig(origins)
+	path := configPath
+	if path != "" || discoverConfigPath(&path) {
+		config = &lint.Config{}
+		origins = configOrigins{}
+		loadConfig(path, "", config, origins, map[string]bool{})
+	}
+	applyFlags(config, origins)
+	normalizeConfig(config, origins)
+	return config, origins
+}
+
+// printConfig prints the effective configuration along with the origin of each value.
+func printConfig(config *lint.Config, origins configOrigins) {
+	origin := func(key string) string {
+		if o, ok := origins[key]; ok {
+			return o
+		}
+		return defaultsOrigin
+	}
+
+	rows := [][]string{
+		{"ignoreGeneratedHeader", fmt.Sprintf("%v", config.IgnoreGeneratedHeader), origin("ignoreGeneratedHeader")},
+		{"confidence", fmt.Sprintf("%v", config.Confidence), origin("confidence")},
+		{"severity", string(config.Severity), origin("severity")},
+		{"errorCode", fmt.Sprintf("%d", config.ErrorCode), origin("errorCode")},
+		{"warningCode", fmt.Sprintf("%d", config.WarningCode), origin("warningCode")},
+		{"exclude", strings.Join(config.Exclude, " "), origin("exclude")},
+		{"buildTags", strings.Join(config.BuildTags, ","), origin("buildTags")},
+		{"tests", fmt.Sprintf("%v", config.Tests), origin("tests")},
+	}
+	for i, build := range config.Builds {
+		rows = append(rows, []string{fmt.Sprintf("build[%d]", i), build.String(), origin("build")})
+	}
+
+	ruleNames := []string{}
+	for name := range config.Rules {
+		ruleNames = append(ruleNames, name)
+	}
+	sort.Strings(ruleNames)
+	for _, name := range ruleNames {
+		rc := config.Rules[name]
+		key := "rule." + name
+		rows = append(rows,
+			[]string{key, "enabled", origin(key)},
+			[]string{key + ".severity", string(rc.Severity), origin(key + ".severity")},
+		)
+		if rc.Arguments != nil {
+			rows = append(rows, []string{key + ".arguments", fmt.Sprintf("%v", rc.Arguments), origin(key + ".arguments")})
+		}
+	}
+
+	directiveNames := []string{}
+	for name := range config.Directives {
+		directiveNames = append(directiveNames, name)
+	}
+	sort.Strings(directiveNames)
+	for _, name := range directiveNames {
+		key := "directive." + name
+		rows = append(rows, []string{key + ".severity", string(config.Directives[name].Severity), origin(key + ".severity")})
 	}
-	normalizeConfig(config)
-	return config
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Key", "Value", "Set by"})
+	table.SetBorder(false)
+	table.SetAutoWrapText(false)
+	table.AppendBulk(rows)
+	table.Render()
 }
 
 func getFormatter() lint.Formatter {
@@ -123,6 +410,34 @@ func getFormatter() lint.Formatter {
 	return formatter
 }
 
+// discoverConfigPath looks for a configuration file in the working directory
+// and its parents up to the module root, then in the home directory.
+// It returns false if no configuration file is found.
+func discoverConfigPath(path *string) bool {
+	if dir, err := os.Getwd(); err == nil {
+		for {
+			for _, name := range configFileNames {
+				candidate := filepath.Join(dir, name)
+				if _, err := os.Stat(candidate); err == nil {
+					*path = candidate
+					return true
+				}
+			}
+			if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+				break // module root reached
+			}
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				break
+			}
+			dir = parent
+		}
+	}
+
+	*path = buildDefaultConfigPath()
+	return *path != ""
+}
+
 func buildDefaultConfigPath() string {
 	var result string
 	if homeDir, err := homedir.Dir(); err == nil {
@@ -135,7 +450,7 @@ func buildDefaultConfigPath() string {
 	return result
 }
 
-func defaultConfig() *lint.Config {
+func defaultConfig(origins configOrigins) *lint.Config {
 	defaultConfig := lint.Config{
 		Confidence: 0.0,
 		Severity:   lint.SeverityWarning,
@@ -143,6 +458,7 @@ func defaultConfig() *lint.Config {
 	}
 	for _, r := range defaultRules {
 		defaultConfig.Rules[r.Name()] = lint.RuleConfig{}
+		origins["rule."+r.Name()] = defaultsOrigin
 	}
 	return &defaultConfig
 }
@@ -158,20 +474,16 @@ func normalizeSplit(strs []string) []string {
 	return res
 }
 
-func getPackages() []*packages.Package {
-	globs := normalizeSplit(flag.Args())
+// getPackages loads the packages matching the given command line arguments.
+func getPackages(config *lint.Config, args []string) []*packages.Package {
+	globs := normalizeSplit(args)
 	if len(globs) == 0 {
 		globs = append(globs, ".")
 	}
 
-	cfg := &gopack.Config{Mode: gopack.LoadSyntax}
-	packages, err := gopack.Load(cfg, globs...)
+	packages, err := lint.LoadPackages("", globs, *config)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "load: %v\n", err)
-		os.Exit(1)
-	}
-	if gopack.PrintErrors(packages) > 0 {
-		os.Exit(1)
+		fail(err.Error())
 	}
 
 	return packages
@@ -188,9 +500,23 @@ func (i *arrayFlags) Set(value string) error {
 	return nil
 }
 
+// configFileNames are the names of the configuration files looked up
+// from the working directory to the module root.
+var configFileNames = []string{"gusano.toml", ".gusano.toml"}
+
 var configPath string
 var excludePaths arrayFlags
 var formatterName string
+var printConfigFlag bool
+var confidence float64
+var setExitStatus bool
+var enableRules arrayFlags
+var disableRules arrayFlags
+var severityName string
+var buildTags arrayFlags
+var includeTests bool
+var outputPath string
+var metricsPath string
 
 var originalUsage = flag.Usage
 
@@ -198,18 +524,36 @@ func init() {
 	flag.Usage = func() {
 		fmt.Println(banner)
 		originalUsage()
+		fmt.Fprint(flag.CommandLine.Output(), "\n"+commandsUsage())
 	}
 	// command line help strings
 	const (
-		configUsage    = "path to the configuration TOML file, defaults to $HOME/gusano.toml, if present (e.g. -config myconf.toml)"
-		excludeUsage   = "list of globs which specify files to be excluded (e.g. -exclude foo/...)"
-		formatterUsage = "formatter to be used for the output (e.g. -formatter stylish)"
+		configUsage      = "path to the configuration TOML file or name of a built-in preset (recommended, strict); defaults to the first gusano.toml or .gusano.toml found from the working directory up to the module root, then $HOME/gusano.toml (e.g. -config myconf.toml)"
+		excludeUsage     = "list of globs which specify files to be excluded (e.g. -exclude foo/...)"
+		formatterUsage   = "formatter to be used for the output (e.g. -formatter stylish)"
+		printConfigUsage = "print the effective configuration and the file that set each value, then exit"
+		confidenceUsage  = "minimum confidence of reported failures, between 0 and 1 (e.g. -confidence 0.5)"
+		exitStatusUsage  = "exit with status 1 when there are failures, unless the configuration sets other exit codes"
+		enableUsage      = "comma separated list of rules to enable (e.g. -enable unused-symbol)"
+		disableUsage     = "comma separated list of rules to disable (e.g. -disable unused-symbol)"
+		severityUsage    = "severity of all failures: warning or error (e.g. -severity error)"
+		tagsUsage        = "comma separated list of build tags used to load packages (e.g. -tags integration,linux)"
+		testsUsage       = "also load and lint test files"
+		outputUsage      = "file where the output is written instead of the standard output (e.g. -output report.json)"
+		metricsUsage     = "file where the coupling and cohesion metrics of the packages are written as JSON (e.g. -metrics metrics.json)"
 	)
 
-	defaultConfigPath := buildDefaultConfigPath()
-
-	flag.StringVar(&configPath, "config", defaultConfigPath, configUsage)
+	flag.StringVar(&configPath, "config", "", configUsage)
 	flag.Var(&excludePaths, "exclude", excludeUsage)
 	flag.StringVar(&formatterName, "formatter", "", formatterUsage)
-	flag.Parse()
+	flag.BoolVar(&printConfigFlag, "print-config", false, printConfigUsage)
+	flag.Float64Var(&confidence, "confidence", 0.8, confidenceUsage)
+	flag.BoolVar(&setExitStatus, "set-exit-status", false, exitStatusUsage)
+	flag.Var(&enableRules, "enable", enableUsage)
+	flag.Var(&disableRules, "disable", disableUsage)
+	flag.StringVar(&severityName, "severity", "", severityUsage)
+	flag.Var(&buildTags, "tags", tagsUsage)
+	flag.BoolVar(&includeTests, "tests", false, testsUsage)
+	flag.StringVar(&outputPath, "output", "", outputUsage)
+	flag.StringVar(&metricsPath, "metrics", "", metricsUsage)
 }
@@ -0,0 +1,457 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/go-homedir"
+
+	"github.com/chavacava/gusano/lint"
+)
+
+// writeTree creates the given files, by slash-separated path, in a temporary
+// directory and returns the directory.
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// setHome makes dir the home directory for the duration of the test.
+func setHome(t *testing.T, dir string) {
+	t.Helper()
+	t.Setenv("HOME", dir)
+	homedir.DisableCache = true
+	t.Cleanup(func() { homedir.DisableCache = false })
+}
+
+// loadTestConfig returns the configuration loaded from the given -config
+// value, as main does without command line flags.
+func loadTestConfig(t *testing.T, path string) (*lint.Config, configOrigins) {
+	t.Helper()
+	configPath = path
+	defer func() { configPath = "" }()
+	return getConfig()
+}
+
+func TestDiscoverConfigPath(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		want  string
+	}{
+		{"none", nil, ""},
+		{"stops at the module root", []string{"gusano.toml"}, ""},
+		{"module root", []string{"mod/.gusano.toml"}, "mod/.gusano.toml"},
+		{"nearest", []string{"mod/gusano.toml", "mod/sub/.gusano.toml"}, "mod/sub/.gusano.toml"},
+		{"gusano.toml first", []string{"mod/sub/.gusano.toml", "mod/sub/gusano.toml"}, "mod/sub/gusano.toml"},
+		{"home", []string{"gusano.toml", "home/gusano.toml"}, "home/gusano.toml"},
+		{"module before home", []string{"mod/gusano.toml", "home/gusano.toml"}, "mod/gusano.toml"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			files := map[string]string{
+				"mod/go.mod":         "module example.com/mod\n",
+				"mod/sub/pkg/pkg.go": "package pkg\n",
+			}
+			for _, name := range test.files {
+				files[name] = ""
+			}
+			root := writeTree(t, files)
+			setHome(t, filepath.Join(root, "home"))
+			t.Chdir(filepath.Join(root, "mod", "sub", "pkg"))
+
+			var path string
+			found := discoverConfigPath(&path)
+			want := ""
+			if test.want != "" {
+				want = filepath.Join(root, filepath.FromSlash(test.want))
+			}
+			if found != (want != "") || path != want {
+				t.Errorf("discoverConfigPath() = %q, %v, want %q", path, found, want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigExtends(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"shared/base.toml": `
+confidence = 0.5
+severity = "error"
+
+[rule.unused-symbol]
+`,
+		"team.toml": `
+extends = ["shared/base.toml"]
+confidence = 0.6
+`,
+		"mod/gusano.toml": `
+extends = ["../team.toml"]
+errorCode = 2
+
+[rule.unused-symbol]
+severity = "warning"
+`,
+	})
+
+	config, origins := loadTestConfig(t, filepath.Join(root, "mod", "gusano.toml"))
+	if config.Confidence != 0.6 || config.Severity != lint.SeverityError || config.ErrorCode != 2 {
+		t.Errorf("confidence, severity, errorCode = %v, %v, %v, want 0.6, error, 2", config.Confidence, config.Severity, config.ErrorCode)
+	}
+	wantRules := lint.RulesConfig{"unused-symbol": {Severity: lint.SeverityWarning}}
+	if !reflect.DeepEqual(config.Rules, wantRules) {
+		t.Errorf("rules = %v, want %v", config.Rules, wantRules)
+	}
+	base, team, local := filepath.Join(root, "shared", "base.toml"), filepath.Join(root, "team.toml"), filepath.Join(root, "mod", "gusano.toml")
+	for key, want := range map[string]string{
+		"confidence":                  team,
+		"severity":                    base,
+		"errorCode":                   local,
+		"rule.unused-symbol":          local,
+		"rule.unused-symbol.severity": local,
+	} {
+		if origins[key] != want {
+			t.Errorf("origin of %s = %q, want %q", key, origins[key], want)
+		}
+	}
+}
+
+func TestLoadConfigExtendsCycle(t *testing.T) {
+	if dir := os.Getenv("GUSANO_TEST_CONFIG_DIR"); dir != "" {
+		loadConfig("a.toml", dir, &lint.Config{}, configOrigins{}, map[string]bool{})
+		return
+	}
+
+	root := writeTree(t, map[string]string{
+		"a.toml":   `extends = ["b/b.toml"]`,
+		"b/b.toml": `extends = ["recommended", "../a.toml"]`,
+	})
+	// loadConfig exits on errors, so it runs in a child process
+	cmd := exec.Command(os.Args[0], "-test.run=^TestLoadConfigExtendsCycle$")
+	cmd.Env = append(os.Environ(), "GUSANO_TEST_CONFIG_DIR="+root)
+	output, err := cmd.CombinedOutput()
+	if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 1 {
+		t.Fatalf("loadConfig with cyclic extends: got error %v, want exit status 1", err)
+	}
+	want := "cyclic extends in the configuration: " + filepath.Join(root, "a.toml")
+	if !strings.Contains(string(output), want) {
+		t.Errorf("loadConfig with cyclic extends printed %q, want %q", output, want)
+	}
+}
+
+func TestPresets(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     string
+		confidence float64
+		severity   lint.Severity
+		errorCode  int
+		rules      lint.RulesConfig
+	}{
+		{
+			name:       "recommended",
+			config:     "recommended",
+			confidence: 0.8,
+			severity:   lint.SeverityWarning,
+			rules:      lint.RulesConfig{"unused-symbol": {Severity: lint.SeverityWarning}},
+		},
+		{
+			name:       "strict",
+			config:     "strict",
+			confidence: 0,
+			severity:   lint.SeverityError,
+			errorCode:  1,
+			rules: lint.RulesConfig{
+				"unused-symbol": {Severity: lint.SeverityError},
+				"dead-store":    {Severity: lint.SeverityError},
+			},
+		},
+		{
+			name: "recommended with overrides",
+			config: `
+extends = ["recommended"]
+severity = "error"
+`,
+			confidence: 0.8,
+			severity:   lint.SeverityError,
+			rules:      lint.RulesConfig{"unused-symbol": {Severity: lint.SeverityError}},
+		},
+		{
+			name: "strict with overrides",
+			config: `
+extends = ["strict"]
+confidence = 0.5
+errorCode = 3
+
+[rule.unused-symbol]
+severity = "warning"
+`,
+			confidence: 0.5,
+			severity:   lint.SeverityError,
+			errorCode:  3,
+			rules: lint.RulesConfig{
+				"unused-symbol": {Severity: lint.SeverityWarning},
+				"dead-store":    {Severity: lint.SeverityError},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := test.config
+			if _, ok := presets[path]; !ok {
+				path = filepath.Join(writeTree(t, map[string]string{"gusano.toml": test.config}), "gusano.toml")
+			}
+			config, _ := loadTestConfig(t, path)
+			if config.Confidence != test.confidence || config.Severity != test.severity || config.ErrorCode != test.errorCode {
+				t.Errorf("confidence, severity, errorCode = %v, %v, %v, want %v, %v, %v",
+					config.Confidence, config.Severity, config.ErrorCode, test.confidence, test.severity, test.errorCode)
+			}
+			if !reflect.DeepEqual(config.Rules, test.rules) {
+				t.Errorf("rules = %v, want %v", config.Rules, test.rules)
+			}
+		})
+	}
+}
+
+func TestPrintConfig(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"team.toml": `
+extends = ["strict"]
+exclude = ["vendor/..."]
+
+[rule.unused-symbol]
+severity = "warning"
+`,
+		"mod/gusano.toml": `
+extends = ["../team.toml"]
+confidence = 0.5
+`,
+	})
+	config, origins := loadTestConfig(t, filepath.Join(root, "mod", "gusano.toml"))
+	for key, origin := range origins {
+		if rel, err := filepath.Rel(root, origin); err == nil && filepath.IsAbs(origin) {
+			origins[key] = filepath.ToSlash(rel)
+		}
+	}
+
+	stdout := os.Stdout
+	defer func() { os.Stdout = stdout }()
+	path := filepath.Join(t.TempDir(), "stdout")
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = out
+	printConfig(config, origins)
+	out.Close()
+	output, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"              KEY             |   VALUE    |       SET BY",
+		"------------------------------+------------+---------------------",
+		"  ignoreGeneratedHeader       | false      | preset:recommended",
+		"  confidence                  |        0.5 | mod/gusano.toml",
+		"  severity                    | error      | preset:strict",
+		"  errorCode                   |          1 | preset:strict",
+		"  warningCode                 |          1 | preset:strict",
+		"  exclude                     | vendor/... | team.toml",
+		"  buildTags                   |            | (defaults)",
+		"  tests                       | false      | (defaults)",
+		"  rule.dead-store             | enabled    | preset:strict",
+		"  rule.dead-store.severity    | error      | preset:strict",
+		"  rule.unused-symbol          | enabled    | team.toml",
+		"  rule.unused-symbol.severity | warning    | team.toml",
+	}
+	// the cells are padded to the width of their column
+	got := strings.Split(strings.TrimSuffix(string(output), "\n"), "\n")
+	for i := range got {
+		got[i] = strings.TrimRight(got[i], " ")
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("printConfig() printed\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+// parseFlags parses args as the command line of gusano, after resetting its
+// flags to their default values.
+func parseFlags(t *testing.T, args ...string) {
+	t.Helper()
+	reset := func() {
+		configPath, formatterName, severityName, outputPath, metricsPath = "", "", "", "", ""
+		excludePaths, enableRules, disableRules, buildTags = nil, nil, nil, nil
+		printConfigFlag, setExitStatus, includeTests = false, false, false
+		confidence = 0.8
+	}
+	reset()
+	commandLine := flag.CommandLine
+	t.Cleanup(func() {
+		flag.CommandLine = commandLine
+		reset()
+	})
+
+	// a new flag set forgets the flags set by previous tests
+	flag.CommandLine = flag.NewFlagSet("gusano", flag.ContinueOnError)
+	commandLine.VisitAll(func(f *flag.Flag) {
+		flag.Var(f.Value, f.Name, f.Usage)
+	})
+	if err := flag.CommandLine.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestApplyFlags(t *testing.T) {
+	path := filepath.Join(writeTree(t, map[string]string{"gusano.toml": `
+confidence = 0.5
+severity = "warning"
+warningCode = 2
+exclude = ["vendor/..."]
+buildTags = ["integration"]
+
+[rule.unused-symbol]
+
+[rule.rule-a]
+severity = "error"
+`}), "gusano.toml")
+
+	tests := []struct {
+		args    []string
+		want    func(config *lint.Config)
+		origins []string
+	}{
+		{
+			args: nil,
+			want: func(config *lint.Config) {},
+		},
+		{
+			args: []string{"-confidence", "0.2"},
+			want: func(config *lint.Config) {
+				config.Confidence = 0.2
+			},
+			origins: []string{"confidence"},
+		},
+		{
+			args: []string{"-enable", "rule-b,rule-a", "-enable", "rule-c"},
+			want: func(config *lint.Config) {
+				config.Rules["rule-b"] = lint.RuleConfig{}
+				config.Rules["rule-c"] = lint.RuleConfig{}
+			},
+			origins: []string{"rule.rule-b", "rule.rule-a", "rule.rule-c"},
+		},
+		{
+			args: []string{"-disable", "rule-a", "-disable", "rule-b"},
+			want: func(config *lint.Config) {
+				delete(config.Rules, "rule-a")
+			},
+		},
+		{
+			args: []string{"-enable", "rule-b", "-disable", "unused-symbol,rule-b"},
+			want: func(config *lint.Config) {
+				delete(config.Rules, "unused-symbol")
+			},
+			origins: []string{"rule.rule-b"},
+		},
+		{
+			args: []string{"-severity", "error"},
+			want: func(config *lint.Config) {
+				config.Severity = lint.SeverityError
+				config.Rules["unused-symbol"] = lint.RuleConfig{Severity: lint.SeverityError}
+			},
+			origins: []string{"severity", "rule.unused-symbol.severity", "rule.rule-a.severity"},
+		},
+		{
+			args: []string{"-set-exit-status"},
+			want: func(config *lint.Config) {
+				config.ErrorCode = 1
+			},
+			origins: []string{"errorCode"},
+		},
+		{
+			args: []string{"-tags", "linux,race", "-tags", "e2e"},
+			want: func(config *lint.Config) {
+				config.BuildTags = []string{"linux", "race", "e2e"}
+			},
+			origins: []string{"buildTags"},
+		},
+		{
+			args: []string{"-tests"},
+			want: func(config *lint.Config) {
+				config.Tests = true
+			},
+			origins: []string{"tests"},
+		},
+		{
+			args: []string{"-exclude", "a.go", "-exclude", "b/..."},
+			want: func(config *lint.Config) {
+				config.Exclude = append(config.Exclude, "a.go", "b/...")
+			},
+			origins: []string{"exclude"},
+		},
+		{
+			args: []string{"-output", "report.txt", "-formatter", "json"},
+			want: func(config *lint.Config) {},
+		},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprint(test.args), func(t *testing.T) {
+			want, wantOrigins := &lint.Config{}, configOrigins{}
+			loadConfig(path, "", want, wantOrigins, map[string]bool{})
+			test.want(want)
+			for _, key := range test.origins {
+				wantOrigins[key] = commandLineOrigin
+			}
+
+			parseFlags(t, test.args...)
+			config, origins := &lint.Config{}, configOrigins{}
+			loadConfig(path, "", config, origins, map[string]bool{})
+			applyFlags(config, origins)
+			if !reflect.DeepEqual(config, want) {
+				t.Errorf("applyFlags() = %+v, want %+v", config, want)
+			}
+			if !reflect.DeepEqual(origins, wantOrigins) {
+				t.Errorf("origins = %v, want %v", origins, wantOrigins)
+			}
+		})
+	}
+}
+
+func TestOutputFlag(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "report.txt")
+	parseFlags(t, "-output", output)
+
+	stdout := os.Stdout
+	defer func() { os.Stdout = stdout }()
+	closeOutput := redirectOutput()
+	fmt.Println("failures")
+	closeOutput()
+
+	got, err := ioutil.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "failures\n" {
+		t.Errorf("-output file content = %q, want %q", got, "failures\n")
+	}
+}
@@ -0,0 +1,92 @@
+package depgraph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Formats of the exported graphs.
+var Formats = []string{"dot", "json", "mermaid"}
+
+// Write writes the graph to w in the given format.
+func (g *Graph) Write(w io.Writer, format string) error {
+	switch format {
+	case "dot":
+		return g.WriteDOT(w)
+	case "json":
+		return g.WriteJSON(w)
+	case "mermaid":
+		return g.WriteMermaid(w)
+	}
+	return fmt.Errorf("unknown graph format %q, expected one of %s", format, strings.Join(Formats, ", "))
+}
+
+// WriteJSON writes the graph as a JSON object with the lists of nodes and edges.
+func (g *Graph) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(g)
+}
+
+// WriteDOT writes the graph in the Graphviz DOT language. Nodes that are not
+// loaded (e.g. the standard library) are drawn with dashed lines and edges
+// are labeled with the number of referenced symbols.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	b := &strings.Builder{}
+	b.WriteString("digraph gusano {\n\trankdir=LR;\n\tnode [shape=box];\n")
+	for _, n := range g.Nodes {
+		style := ""
+		if !n.Loaded {
+			style = ", style=dashed"
+		}
+		fmt.Fprintf(b, "\t\"%s\" [label=\"%s\\n%s\"%s];\n", dotEscape(n.ID), dotEscape(n.ID), n.metrics(), style)
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(b, "\t\"%s\" -> \"%s\" [label=\"%d\"];\n", dotEscape(e.From), dotEscape(e.To), e.Symbols)
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// dotEscape escapes s for a double-quoted DOT string.
+func dotEscape(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), `"`, `\"`)
+}
+
+// WriteMermaid writes the graph as a Mermaid flowchart.
+func (g *Graph) WriteMermaid(w io.Writer) error {
+	b := &strings.Builder{}
+	b.WriteString("graph LR\n")
+	ids := map[string]string{}
+	for i, n := range g.Nodes {
+		ids[n.ID] = "n" + strconv.Itoa(i)
+		label := strings.ReplaceAll(n.ID, `"`, "#quot;") + "<br/>" + n.metrics()
+		if n.Loaded {
+			fmt.Fprintf(b, "\t%s[\"%s\"]\n", ids[n.ID], label)
+		} else {
+			fmt.Fprintf(b, "\t%s([\"%s\"])\n", ids[n.ID], label)
+		}
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(b, "\t%s -->|%d| %s\n", ids[e.From], e.Symbols, ids[e.To])
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// metrics returns a short description of the size of the node.
+func (n *Node) metrics() string {
+	parts := []string{}
+	if n.Packages > 1 {
+		parts = append(parts, fmt.Sprintf("%d packages", n.Packages))
+	}
+	if n.Loaded {
+		parts = append(parts, fmt.Sprintf("%d files, %d lines", n.Files, n.Lines))
+	}
+	parts = append(parts, fmt.Sprintf("%d decls", n.Decls))
+	return strings.Join(parts, ", ")
+}
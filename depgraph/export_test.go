@@ -0,0 +1,69 @@
+package depgraph
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// checkGolden compares got with the content of the golden file of the test
+// data, or updates the file with the -update flag.
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	golden := filepath.Join("testdata", name)
+	if *update {
+		if err := ioutil.WriteFile(golden, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output does not match %s; got:\n%s", golden, got)
+	}
+}
+
+// exportedGraph is a graph with a loaded node collapsing two packages, a
+// loaded node and a node that is only imported, with a quote in its ID.
+func exportedGraph() *Graph {
+	return &Graph{
+		Nodes: []*Node{
+			{ID: "example.com/mod/app", Module: "example.com/mod", Internal: true, Loaded: true, Packages: 2, Files: 3, Lines: 120, Decls: 10, Exported: 4},
+			{ID: "example.com/mod/domain", Module: "example.com/mod", Internal: true, Loaded: true, Packages: 1, Files: 1, Lines: 30, Decls: 2, Exported: 2},
+			{ID: `std"lib`, Packages: 1, Decls: 50, Exported: 45},
+		},
+		Edges: []*Edge{
+			{From: "example.com/mod/app", To: "example.com/mod/domain", Symbols: 2},
+			{From: "example.com/mod/app", To: `std"lib`, Symbols: 1},
+			{From: "example.com/mod/domain", To: `std"lib`, Symbols: 3},
+		},
+	}
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		format, golden string
+	}{
+		{"dot", "graph.dot"},
+		{"json", "graph.json"},
+		{"mermaid", "graph.mmd"},
+	}
+	for _, test := range tests {
+		var b bytes.Buffer
+		if err := exportedGraph().Write(&b, test.format); err != nil {
+			t.Fatalf("Write(%s): %v", test.format, err)
+		}
+		checkGolden(t, test.golden, b.Bytes())
+	}
+
+	if err := exportedGraph().Write(&bytes.Buffer{}, "svg"); err == nil {
+		t.Error("Write(svg): expected an error")
+	}
+}
@@ -0,0 +1,327 @@
+// Package depgraph builds the import graph of loaded packages, optionally
+// collapsed to directories or modules, and exports it for visualization.
+package depgraph
+
+import (
+	"go/types"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/chavacava/gusano/lint"
+	"golang.org/x/tools/go/packages"
+)
+
+// Level is the granularity of the nodes of a graph.
+type Level string
+
+// Levels of graphs.
+const (
+	LevelPackage   Level = "package"
+	LevelDirectory Level = "directory"
+	LevelModule    Level = "module"
+)
+
+// Options are the options for building a graph.
+type Options struct {
+	// Level is the granularity of the nodes
+	Level Level
+	// DirDepth is, at directory level, the number of path elements below the
+	// module path that identify a directory
+	DirDepth int
+	// InternalOnly restricts the graph to the packages of the modules of the
+	// loaded packages
+	InternalOnly bool
+	// Focus, if set, restricts the graph to the nodes connected to the node
+	// of this package, by imports or by importers
+	Focus string
+	// Depth, if not negative, restricts the graph to the nodes at most
+	// Depth imports away from the focus, or from the loaded packages
+	Depth int
+}
+
+// Node is a package, or a set of packages collapsed together.
+type Node struct {
+	ID string `json:"id"`
+	// Module is the path of the module of the node, empty for the standard library
+	Module string `json:"module,omitempty"`
+	// Internal is true if the node belongs to a module of the loaded packages
+	Internal bool `json:"internal"`
+	// Loaded is true if the node holds loaded packages, whose syntax is known
+	Loaded bool `json:"loaded"`
+	// Packages is the number of packages collapsed in the node
+	Packages int `json:"packages"`
+	// Files and Lines count the source files of the loaded packages
+	Files int `json:"files"`
+	Lines int `json:"lines"`
+	// Decls and Exported count the package-level declarations
+	Decls    int `json:"decls"`
+	Exported int `json:"exported"`
+
+	packages map[string]bool
+	files    map[string]bool
+}
+
+// Edge is an import dependency between two nodes.
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Symbols is the number of distinct symbols of To referred to by From
+	Symbols int `json:"symbols"`
+
+	symbols map[string]bool
+}
+
+// Graph is an import graph.
+type Graph struct {
+	Nodes []*Node `json:"nodes"`
+	Edges []*Edge `json:"edges"`
+
+	nodes map[string]*Node
+	edges map[[2]string]*Edge
+	// roots are the nodes of the loaded packages
+	roots map[string]bool
+}
+
+// Build returns the import graph of the loaded packages and of the packages
+// they import.
+func Build(pkgs []*packages.Package, options Options) *Graph {
+	g := &Graph{nodes: map[string]*Node{}, edges: map[[2]string]*Edge{}, roots: map[string]bool{}}
+
+	modules := map[string]bool{}
+	for _, p := range pkgs {
+		if p.Module != nil {
+			modules[p.Module.Path] = true
+		}
+	}
+	keyOf := func(p *packages.Package) (string, string) {
+		pkgPath := strings.TrimSuffix(p.PkgPath, "_test")
+		modPath := moduleOf(p, modules)
+		switch options.Level {
+		case LevelModule:
+			if modPath == "" {
+				return "std", ""
+			}
+			return modPath, modPath
+		case LevelDirectory:
+			return DirectoryOf(pkgPath, modPath, options.DirDepth), modPath
+		}
+		return pkgPath, modPath
+	}
+	node := func(p *packages.Package) *Node {
+		id, modPath := keyOf(p)
+		n, ok := g.nodes[id]
+		if !ok {
+			n = &Node{ID: id, Module: modPath, Internal: modules[modPath], packages: map[string]bool{}, files: map[string]bool{}}
+			g.nodes[id] = n
+		}
+		if pkgPath := strings.TrimSuffix(p.PkgPath, "_test"); !n.packages[pkgPath] {
+			n.packages[pkgPath] = true
+			n.Packages++
+			if p.Types != nil {
+				scope := p.Types.Scope()
+				for _, name := range scope.Names() {
+					n.Decls++
+					if scope.Lookup(name).Exported() {
+						n.Exported++
+					}
+				}
+			}
+		}
+		return n
+	}
+
+	for _, p := range pkgs {
+		from := node(p)
+		from.Loaded = true
+		g.roots[from.ID] = true
+		for _, f := range p.Syntax {
+			tf := p.Fset.File(f.Pos())
+			if tf == nil || from.files[tf.Name()] {
+				continue
+			}
+			from.files[tf.Name()] = true
+			from.Files++
+			from.Lines += tf.LineCount()
+		}
+
+		for _, imp := range p.Imports {
+			to := node(imp)
+			if to.ID == from.ID {
+				continue
+			}
+			e := g.edge(from.ID, to.ID)
+			if p.TypesInfo == nil || imp.Types == nil {
+				continue
+			}
+			for _, obj := range p.TypesInfo.Uses {
+				if obj.Pkg() == imp.Types {
+					if key := symbolKey(obj); key != "" {
+						e.symbols[key] = true
+					}
+				}
+			}
+		}
+	}
+
+	g.filter(options)
+	g.sort()
+	return g
+}
+
+// moduleOf returns the path of the module of the package. The module of
+// packages that are only imported is not loaded: it is found among the
+// modules of the loaded packages.
+func moduleOf(p *packages.Package, modules map[string]bool) string {
+	if p.Module != nil {
+		return p.Module.Path
+	}
+	result := ""
+	for m := range modules {
+		if (p.PkgPath == m || strings.HasPrefix(p.PkgPath, m+"/")) && len(m) > len(result) {
+			result = m
+		}
+	}
+	return result
+}
+
+func (g *Graph) edge(from, to string) *Edge {
+	key := [2]string{from, to}
+	e, ok := g.edges[key]
+	if !ok {
+		e = &Edge{From: from, To: to, symbols: map[string]bool{}}
+		g.edges[key] = e
+	}
+	return e
+}
+
+// symbolKey identifies a symbol referred to from another package.
+func symbolKey(obj types.Object) string {
+	if _, ok := obj.(*types.PkgName); ok {
+		return ""
+	}
+	if key := lint.ObjectKey(lint.Origin(obj)); key != "" {
+		return key
+	}
+	return obj.Pkg().Path() + " " + obj.Name()
+}
+
+// DirectoryOf returns the directory of the package made of the module path
+// and, at most, depth elements of the path below it.
+func DirectoryOf(pkgPath, modPath string, depth int) string {
+	base, rel := "", pkgPath
+	if modPath != "" && (pkgPath == modPath || strings.HasPrefix(pkgPath, modPath+"/")) {
+		base, rel = modPath, strings.TrimPrefix(strings.TrimPrefix(pkgPath, modPath), "/")
+	}
+	if rel == "" {
+		return base
+	}
+	elems := strings.Split(rel, "/")
+	if len(elems) > depth {
+		elems = elems[:depth]
+	}
+	if base == "" {
+		return strings.Join(elems, "/")
+	}
+	return path.Join(append([]string{base}, elems...)...)
+}
+
+// filter removes the nodes, and their edges, excluded by the options.
+func (g *Graph) filter(options Options) {
+	keep := map[string]bool{}
+	for id, n := range g.nodes {
+		keep[id] = !options.InternalOnly || n.Internal
+	}
+
+	var starts []string
+	if options.Focus != "" {
+		for id, n := range g.nodes {
+			if keep[id] && (id == options.Focus || n.packages[options.Focus]) {
+				starts = append(starts, id)
+			}
+		}
+	} else if options.Depth >= 0 {
+		for id := range g.roots {
+			if keep[id] {
+				starts = append(starts, id)
+			}
+		}
+	}
+
+	if options.Focus != "" || options.Depth >= 0 {
+		succ, pred := map[string][]string{}, map[string][]string{}
+		for key := range g.edges {
+			if keep[key[0]] && keep[key[1]] {
+				succ[key[0]] = append(succ[key[0]], key[1])
+				pred[key[1]] = append(pred[key[1]], key[0])
+			}
+		}
+		reached := distances(starts, succ, options.Depth)
+		if options.Focus != "" {
+			for id := range distances(starts, pred, options.Depth) {
+				reached[id] = true
+			}
+		}
+		for id := range keep {
+			keep[id] = keep[id] && reached[id]
+		}
+	}
+
+	for id := range g.nodes {
+		if !keep[id] {
+			delete(g.nodes, id)
+		}
+	}
+	for key := range g.edges {
+		if !keep[key[0]] || !keep[key[1]] {
+			delete(g.edges, key)
+		}
+	}
+}
+
+// distances returns the nodes reached from the starts by following next,
+// in at most depth steps if depth is not negative.
+func distances(starts []string, next map[string][]string, depth int) map[string]bool {
+	reached := map[string]bool{}
+	frontier := []string{}
+	for _, s := range starts {
+		if !reached[s] {
+			reached[s] = true
+			frontier = append(frontier, s)
+		}
+	}
+	for step := 0; len(frontier) > 0 && (depth < 0 || step < depth); step++ {
+		var nextFrontier []string
+		for _, id := range frontier {
+			for _, to := range next[id] {
+				if !reached[to] {
+					reached[to] = true
+					nextFrontier = append(nextFrontier, to)
+				}
+			}
+		}
+		frontier = nextFrontier
+	}
+	return reached
+}
+
+// sort fills the exported lists of nodes and edges in a stable order.
+func (g *Graph) sort() {
+	g.Nodes = make([]*Node, 0, len(g.nodes))
+	for _, n := range g.nodes {
+		g.Nodes = append(g.Nodes, n)
+	}
+	sort.Slice(g.Nodes, func(i, j int) bool { return g.Nodes[i].ID < g.Nodes[j].ID })
+
+	g.Edges = make([]*Edge, 0, len(g.edges))
+	for _, e := range g.edges {
+		e.Symbols = len(e.symbols)
+		g.Edges = append(g.Edges, e)
+	}
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].From != g.Edges[j].From {
+			return g.Edges[i].From < g.Edges[j].From
+		}
+		return g.Edges[i].To < g.Edges[j].To
+	})
+}
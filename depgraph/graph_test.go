@@ -0,0 +1,92 @@
+package depgraph
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/chavacava/gusano/lint"
+)
+
+// base is the path of the testdata packages of the import graphs, whose
+// imports cross their layers: app imports db and domain imports events.
+const base = "github.com/chavacava/gusano/testdata/pkg14"
+
+func TestBuildFilters(t *testing.T) {
+	pkgs, err := lint.LoadPackages("../testdata", []string{"./pkg14/..."}, lint.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const (
+		app    = base + "/app"
+		events = base + "/app/events"
+		server = base + "/cmd/server"
+		domain = base + "/domain"
+		db     = base + "/infra/db"
+	)
+	tests := []struct {
+		name    string
+		options Options
+		nodes   []string
+		edges   [][2]string
+	}{
+		{
+			name:    "all",
+			options: Options{Depth: -1},
+			nodes:   []string{"fmt", app, events, server, domain, db},
+		},
+		{
+			name:    "loaded packages",
+			options: Options{Depth: 0},
+			nodes:   []string{app, events, server, domain, db},
+		},
+		{
+			name:    "internal",
+			options: Options{InternalOnly: true, Depth: -1},
+			nodes:   []string{app, events, server, domain, db},
+			edges:   [][2]string{{app, events}, {app, domain}, {app, db}, {server, app}, {server, db}, {domain, events}, {db, domain}},
+		},
+		{
+			name:    "focus",
+			options: Options{InternalOnly: true, Focus: events, Depth: -1},
+			nodes:   []string{app, events, server, domain, db},
+		},
+		{
+			name:    "focus with depth",
+			options: Options{Focus: db, Depth: 1},
+			nodes:   []string{"fmt", app, server, domain, db},
+			edges:   [][2]string{{app, domain}, {app, db}, {server, "fmt"}, {server, app}, {server, db}, {domain, "fmt"}, {db, "fmt"}, {db, domain}},
+		},
+		{
+			name:    "internal focus with depth",
+			options: Options{InternalOnly: true, Focus: events, Depth: 1},
+			nodes:   []string{app, events, domain},
+			edges:   [][2]string{{app, events}, {app, domain}, {domain, events}},
+		},
+		{
+			name:    "directories",
+			options: Options{Level: LevelDirectory, DirDepth: 3, InternalOnly: true, Depth: -1},
+			nodes:   []string{base + "/app", base + "/cmd", base + "/domain", base + "/infra"},
+			edges:   [][2]string{{base + "/app", base + "/domain"}, {base + "/app", base + "/infra"}, {base + "/cmd", base + "/app"}, {base + "/cmd", base + "/infra"}, {base + "/domain", base + "/app"}, {base + "/infra", base + "/domain"}},
+		},
+	}
+	for _, test := range tests {
+		g := Build(pkgs, test.options)
+		nodes := []string{}
+		for _, n := range g.Nodes {
+			nodes = append(nodes, n.ID)
+		}
+		if !reflect.DeepEqual(nodes, test.nodes) {
+			t.Errorf("%s: nodes = %v, want %v", test.name, nodes, test.nodes)
+		}
+		if test.edges == nil {
+			continue
+		}
+		edges := [][2]string{}
+		for _, e := range g.Edges {
+			edges = append(edges, [2]string{e.From, e.To})
+		}
+		if !reflect.DeepEqual(edges, test.edges) {
+			t.Errorf("%s: edges = %v, want %v", test.name, edges, test.edges)
+		}
+	}
+}
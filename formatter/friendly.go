@@ -42,9 +42,14 @@ func (f *Friendly) Format(failures <-chan lint.Failure, config lint.Config) (str
 	warningMap := map[string]int{}
 	totalErrors := 0
 	totalWarnings := 0
+	duplicates := []lint.Failure{}
 	for failure := range failures {
 		sev := severity(config, failure)
-		f.printFriendlyFailure(failure, sev)
+		if failure.Category == "duplication" && len(failure.Related) > 0 {
+			duplicates = append(duplicates, failure)
+		} else {
+			f.printFriendlyFailure(failure, sev)
+		}
 		if sev == lint.SeverityWarning {
 			warningMap[failure.RuleName] = warningMap[failure.RuleName] + 1
 			totalWarnings++
@@ -54,6 +59,7 @@ func (f *Friendly) Format(failures <-chan lint.Failure, config lint.Config) (str
 			totalErrors++
 		}
 	}
+	f.printDuplicates(duplicates, config)
 	f.printSummary(totalErrors, totalWarnings)
 	f.printStatistics(color.RedString("Errors:"), errorMap)
 	f.printStatistics(color.YellowString("Warnings:"), warningMap)
@@ -79,6 +85,23 @@ func (f *Friendly) printFilePosition(failure lint.Failure) {
 	fmt.Printf("  %s:%d:%d", failure.GetFilename(), failure.Position.Start.Line, failure.Position.Start.Column)
 }
 
+// printDuplicates prints the groups of duplicated code in their own section,
+// with the positions of all the copies.
+func (f *Friendly) printDuplicates(failures []lint.Failure, config lint.Config) {
+	if len(failures) == 0 {
+		return
+	}
+	fmt.Println("Duplicated code:")
+	fmt.Println()
+	for _, failure := range failures {
+		f.printHeaderRow(failure, severity(config, failure))
+		for _, p := range append([]lint.FailurePosition{failure.Position}, failure.Related...) {
+			fmt.Printf("  %s:%d-%d\n", p.Start.Filename, p.Start.Line, p.End.Line)
+		}
+		fmt.Println()
+	}
+}
+
 type statEntry struct {
 	name     string
 	failures int
@@ -3,6 +3,9 @@ package formatter
 import "github.com/chavacava/gusano/lint"
 
 func severity(config lint.Config, failure lint.Failure) lint.Severity {
+	if failure.Severity != "" {
+		return failure.Severity
+	}
 	if config, ok := config.Rules[failure.RuleName]; ok && config.Severity == lint.SeverityError {
 		return lint.SeverityError
 	}
@@ -1,11 +1,20 @@
 module github.com/chavacava/gusano
 
-go 1.12
+go 1.25.0
 
 require (
 	github.com/BurntSushi/toml v0.3.1
 	github.com/fatih/color v1.9.0
 	github.com/mitchellh/go-homedir v1.1.0
 	github.com/olekukonko/tablewriter v0.0.4
-	golang.org/x/tools v0.0.0-20200430040329-4b814e061378
+	golang.org/x/mod v0.37.0
+	golang.org/x/tools v0.47.0
+)
+
+require (
+	github.com/mattn/go-colorable v0.1.4 // indirect
+	github.com/mattn/go-isatty v0.0.11 // indirect
+	github.com/mattn/go-runewidth v0.0.7 // indirect
+	golang.org/x/sync v0.21.0 // indirect
+	golang.org/x/sys v0.46.0 // indirect
 )
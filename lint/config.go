@@ -1,5 +1,7 @@
 package lint
 
+import "strings"
+
 // Arguments is type used for the arguments of a rule.
 type Arguments = []interface{}
 
@@ -20,6 +22,29 @@ type DirectiveConfig struct {
 // DirectivesConfig defines the config for all directives.
 type DirectivesConfig = map[string]DirectiveConfig
 
+// BuildConfig defines a build configuration under which packages are loaded.
+type BuildConfig struct {
+	GOOS   string   `toml:"goos"`
+	GOARCH string   `toml:"goarch"`
+	Tags   []string `toml:"tags"`
+}
+
+// String returns a short description of the build configuration.
+func (b BuildConfig) String() string {
+	goos, goarch := b.GOOS, b.GOARCH
+	if goos == "" {
+		goos = "$GOOS"
+	}
+	if goarch == "" {
+		goarch = "$GOARCH"
+	}
+	result := goos + "/" + goarch
+	if len(b.Tags) > 0 {
+		result += " tags=" + strings.Join(b.Tags, ",")
+	}
+	return result
+}
+
 // Config defines the config of the linter.
 type Config struct {
 	IgnoreGeneratedHeader bool `toml:"ignoreGeneratedHeader"`
@@ -29,4 +54,12 @@ type Config struct {
 	ErrorCode             int              `toml:"errorCode"`
 	WarningCode           int              `toml:"warningCode"`
 	Directives            DirectivesConfig `toml:"directive"`
+	// Exclude lists globs of files whose failures are not reported.
+	Exclude []string `toml:"exclude"`
+	// BuildTags lists the build tags used when loading packages.
+	BuildTags []string `toml:"buildTags"`
+	// Tests makes the linter load and lint test packages too.
+	Tests bool `toml:"tests"`
+	// Builds lists additional build configurations under which packages are loaded.
+	Builds []BuildConfig `toml:"build"`
 }
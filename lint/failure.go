@@ -21,6 +21,19 @@ type FailurePosition struct {
 	End   token.Position
 }
 
+// Edit defines the replacement of the text between two positions of a file.
+type Edit struct {
+	Start   token.Position
+	End     token.Position
+	NewText string
+}
+
+// SuggestedFix defines a set of edits that fixes a failure.
+type SuggestedFix struct {
+	Message string
+	Edits   []Edit
+}
+
 // Failure defines a struct for a linting failure.
 type Failure struct {
 	Failure    string
@@ -29,8 +42,14 @@ type Failure struct {
 	Position   FailurePosition
 	Node       ast.Node `json:"-"`
 	Confidence float64
+	// Severity, if set, overrides the severity configured for the rule
+	Severity Severity `json:"-"`
 	// For future use
 	ReplacementLine string
+	SuggestedFix    *SuggestedFix `json:",omitempty"`
+	// Related are other positions involved in the failure (e.g. the other
+	// copies of duplicated code)
+	Related []FailurePosition `json:",omitempty"`
 }
 
 // GetFilename returns the filename.
@@ -1,8 +1,10 @@
 package lint
 
 import (
-	"fmt"
 	"os"
+	"path"
+	"path/filepath"
+	"strings"
 	"sync"
 
 	"golang.org/x/tools/go/packages"
@@ -28,6 +30,41 @@ var (
 
 // Lint lints a set of files with the specified rule.
 func (l *Linter) Lint(pkgs []*packages.Package, ruleSet []Rule, config Config) (<-chan Failure, error) {
+	for _, r := range ruleSet {
+		if cr, ok := r.(ConfigurableRule); ok {
+			if err := cr.CheckArguments(config.Rules[r.Name()].Arguments); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	groups := [][]*Package{}
+	for _, variants := range groupVariants(pkgs) {
+		group := make([]*Package, 0, len(variants))
+		for _, variant := range variants {
+			rPkg, err := newPackage(variant)
+			if err != nil {
+				return nil, err
+			}
+			group = append(group, rPkg)
+		}
+		groups = append(groups, group)
+	}
+
+	moduleRules := []ModuleRule{}
+	for _, r := range ruleSet {
+		if mr, ok := r.(ModuleRule); ok {
+			moduleRules = append(moduleRules, mr)
+		}
+	}
+	var module *Module
+	if len(moduleRules) > 0 {
+		var err error
+		if module, err = NewModule(pkgs); err != nil {
+			return nil, err
+		}
+	}
+
 	failures := make(chan Failure)
 	stopFiltering := make(chan struct{})
 	unfilteredFailures := make(chan Failure)
@@ -38,22 +75,33 @@ func (l *Linter) Lint(pkgs []*packages.Package, ruleSet []Rule, config Config) (
 			case <-stopFiltering:
 				return
 			case f := <-unfilteredFailures:
-				// Implement failure filtering
+				if isExcluded(f.GetFilename(), config.Exclude) {
+					continue
+				}
 				failures <- f
 			}
 		}
 	}()
 
 	var wg sync.WaitGroup
-	for _, pkg := range pkgs {
+	for _, variants := range groups {
 		wg.Add(1)
-		go func(pkg *packages.Package) {
-			if err := l.lintPackage(pkg, ruleSet, config, unfilteredFailures); err != nil {
-				fmt.Fprintln(os.Stderr, err)
-				os.Exit(1)
+		go func(variants []*Package) {
+			defer wg.Done()
+			if len(variants) == 1 {
+				l.lintPackage(variants[0], ruleSet, config, unfilteredFailures)
+			} else {
+				l.lintVariants(variants, ruleSet, config, unfilteredFailures)
 			}
+		}(variants)
+	}
+
+	if module != nil {
+		wg.Add(1)
+		go func() {
 			defer wg.Done()
-		}(pkg)
+			l.lintModule(module, moduleRules, config, unfilteredFailures)
+		}()
 	}
 
 	go func() {
@@ -66,7 +114,105 @@ func (l *Linter) Lint(pkgs []*packages.Package, ruleSet []Rule, config Config) (
 	return failures, nil
 }
 
-func (l *Linter) lintPackage(pkg *packages.Package, ruleSet []Rule, config Config, failures chan Failure) error {
+// groupVariants groups the packages loaded under different build
+// configurations by package path, preserving the loading order.
+func groupVariants(pkgs []*packages.Package) [][]*packages.Package {
+	index := map[string]int{}
+	result := [][]*packages.Package{}
+	for _, pkg := range pkgs {
+		i, ok := index[pkg.PkgPath]
+		if !ok {
+			i = len(result)
+			index[pkg.PkgPath] = i
+			result = append(result, nil)
+		}
+		result[i] = append(result[i], pkg)
+	}
+	return result
+}
+
+type failureKey struct {
+	rule     string
+	filename string
+	line     int
+	column   int
+	failure  string
+}
+
+func keyOf(f Failure) failureKey {
+	return failureKey{f.RuleName, f.GetFilename(), f.Position.Start.Line, f.Position.Start.Column, f.Failure}
+}
+
+// lintVariants lints the variants of a package loaded under several build
+// configurations and merges their failures: failures found in more than one
+// variant are reported once, and failures of consensus rules are reported only
+// if found in every variant compiling the file of the failure.
+func (l *Linter) lintVariants(variants []*Package, ruleSet []Rule, config Config, failures chan Failure) {
+	consensus := map[string]bool{}
+	for _, r := range ruleSet {
+		if cr, ok := r.(ConsensusRule); ok && cr.RequiresConsensus() {
+			consensus[r.Name()] = true
+		}
+	}
+
+	found := map[failureKey]int{}
+	ordered := []Failure{}
+	for _, variant := range variants {
+		variantFailures := make(chan Failure)
+		done := make(chan struct{})
+		go func() {
+			seen := map[failureKey]bool{}
+			for f := range variantFailures {
+				k := keyOf(f)
+				if seen[k] {
+					continue
+				}
+				seen[k] = true
+				if found[k] == 0 {
+					ordered = append(ordered, f)
+				}
+				found[k]++
+			}
+			close(done)
+		}()
+		l.lintPackage(variant, ruleSet, config, variantFailures)
+		close(variantFailures)
+		<-done
+	}
+
+	for _, f := range ordered {
+		if consensus[f.RuleName] && f.GetFilename() != "" {
+			required := 0
+			for _, variant := range variants {
+				if variant.files[f.GetFilename()] != nil {
+					required++
+				}
+			}
+			if found[keyOf(f)] < required {
+				continue
+			}
+		}
+		failures <- f
+	}
+}
+
+// lintModule applies the module rules to all the loaded packages.
+func (l *Linter) lintModule(module *Module, rules []ModuleRule, config Config, failures chan Failure) {
+	for _, r := range rules {
+		r.ApplyToModule(module, config.Rules[r.Name()].Arguments, failures)
+	}
+}
+
+func (l *Linter) lintPackage(rPkg *Package, ruleSet []Rule, config Config, failures chan Failure) {
+	if len(rPkg.files) == 0 {
+		return
+	}
+
+	rPkg.lint(ruleSet, config, failures)
+}
+
+// newPackage creates the Package of a loaded package.
+func newPackage(pkg *packages.Package) (*Package, error) {
 	rPkg := &Package{
 		fset:      pkg.Fset,
 		files:     map[string]*File{},
@@ -75,6 +221,9 @@ func (l *Linter) lintPackage(pkg *packages.Package, ruleSet []Rule, config Confi
 		TypesInfo: pkg.TypesInfo,
 		TypesPkg:  pkg.Types,
 	}
+	if pkg.Module != nil {
+		rPkg.ModulePath = pkg.Module.Path
+	}
 
 	for _, fileAST := range pkg.Syntax {
 		/*
@@ -88,20 +237,57 @@ func (l *Linter) lintPackage(pkg *packages.Package, ruleSet []Rule, config Confi
 			}
 		*/
 
-		file, err := NewFile(fileAST.Name.Name, rPkg, fileAST)
+		filename := pkg.Fset.Position(fileAST.Pos()).Filename
+		file, err := NewFile(filename, rPkg, fileAST)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		rPkg.files[fileAST.Name.Name] = file
+		rPkg.files[filename] = file
 	}
 
-	if len(rPkg.files) == 0 {
-		return nil
+	return rPkg, nil
+}
+
+// isExcluded returns true if the given file matches one of the exclusion globs.
+// Globs are relative to the working directory; a glob ending with "/..."
+// matches every file under the directory, and a glob without a path separator
+// is also matched against the base name of the file.
+func isExcluded(filename string, globs []string) bool {
+	if filename == "" || len(globs) == 0 {
+		return false
 	}
 
-	rPkg.lint(ruleSet, config, failures)
+	rel := filename
+	if wd, err := os.Getwd(); err == nil {
+		if r, err := filepath.Rel(wd, filename); err == nil {
+			rel = r
+		}
+	}
+	rel = filepath.ToSlash(rel)
 
-	return nil
+	for _, glob := range globs {
+		glob = filepath.ToSlash(filepath.Clean(glob))
+		if glob == "..." {
+			return true
+		}
+		if strings.HasSuffix(glob, "/...") {
+			dir := strings.TrimSuffix(glob, "/...")
+			if rel == dir || strings.HasPrefix(rel, dir+"/") {
+				return true
+			}
+			continue
+		}
+		if ok, _ := path.Match(glob, rel); ok {
+			return true
+		}
+		if !strings.Contains(glob, "/") {
+			if ok, _ := path.Match(glob, path.Base(rel)); ok {
+				return true
+			}
+		}
+	}
+
+	return false
 }
 
 // isGenerated reports whether the source file is generated code
@@ -0,0 +1,132 @@
+package lint
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"golang.org/x/tools/go/packages"
+)
+
+func TestGroupVariants(t *testing.T) {
+	pkgs := []*packages.Package{
+		{ID: "b [linux]", PkgPath: "b"},
+		{ID: "a [linux]", PkgPath: "a"},
+		{ID: "b [windows]", PkgPath: "b"},
+		{ID: "c", PkgPath: "c"},
+		{ID: "a [windows]", PkgPath: "a"},
+	}
+	var got [][]string
+	for _, variants := range groupVariants(pkgs) {
+		ids := []string{}
+		for _, v := range variants {
+			ids = append(ids, v.ID)
+		}
+		got = append(got, ids)
+	}
+	want := [][]string{{"b [linux]", "b [windows]"}, {"a [linux]", "a [windows]"}, {"c"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("groupVariants() = %v, want %v", got, want)
+	}
+}
+
+// fileCountRule reports every file with the number of files of its package,
+// that depends on the build configuration.
+type fileCountRule struct {
+	consensus bool
+}
+
+func (*fileCountRule) Name() string { return "file-count" }
+
+func (r *fileCountRule) RequiresConsensus() bool { return r.consensus }
+
+func (*fileCountRule) ApplyToFile(file *File, _ Arguments) []Failure {
+	return []Failure{{
+		Failure:    fmt.Sprintf("package of %d files", len(file.Pkg.files)),
+		RuleName:   "file-count",
+		Position:   ToFailurePosition(file.AST.Name.Pos(), file.AST.Name.End(), file),
+		Confidence: 1,
+	}}
+}
+
+func (*fileCountRule) ApplyToPackage(*Package, Arguments, chan Failure) {}
+
+func TestLintVariants(t *testing.T) {
+	var pkgs []*packages.Package
+	for _, goos := range []string{"linux", "windows"} {
+		cfg := &packages.Config{Mode: packages.LoadSyntax, Dir: "../testdata/pkg4", Env: append(os.Environ(), "GOOS="+goos)}
+		variant, err := packages.Load(cfg, ".")
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkgs = append(pkgs, variant...)
+	}
+
+	// build-tags_linux.go is only compiled under linux, build-tags.go has a
+	// different failure in each variant
+	tests := []struct {
+		consensus bool
+		want      []string
+	}{
+		{false, []string{"build-tags.go: package of 1 files", "build-tags.go: package of 2 files", "build-tags_linux.go: package of 2 files"}},
+		{true, []string{"build-tags_linux.go: package of 2 files"}},
+	}
+	linter := New(nil)
+	for _, test := range tests {
+		failures, err := linter.Lint(pkgs, []Rule{&fileCountRule{test.consensus}}, Config{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := []string{}
+		for f := range failures {
+			got = append(got, filepath.Base(f.GetFilename())+": "+f.Failure)
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Lint() with consensus %v = %v, want %v", test.consensus, got, test.want)
+		}
+	}
+}
+
+// checkedRule is a rule rejecting any argument.
+type checkedRule struct {
+	applied bool
+}
+
+func (*checkedRule) Name() string { return "checked" }
+
+func (r *checkedRule) ApplyToFile(*File, Arguments) []Failure {
+	r.applied = true
+	return nil
+}
+
+func (r *checkedRule) ApplyToPackage(*Package, Arguments, chan Failure) {
+	r.applied = true
+}
+
+func (*checkedRule) CheckArguments(arguments Arguments) error {
+	if len(arguments) > 0 {
+		return errors.New("invalid argument for checked rule")
+	}
+	return nil
+}
+
+func TestLintChecksArguments(t *testing.T) {
+	pkgs, err := LoadPackages("../testdata", []string{"./pkg1"}, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &checkedRule{}
+	config := Config{Rules: RulesConfig{"checked": {Arguments: Arguments{"x"}}}}
+	linter := New(nil)
+	if _, err := linter.Lint(pkgs, []Rule{r}, config); err == nil || err.Error() != "invalid argument for checked rule" {
+		t.Errorf("Lint() with invalid arguments: error %v, want the error of CheckArguments", err)
+	}
+	if r.applied {
+		t.Error("Lint() applied a rule with invalid arguments")
+	}
+}
@@ -0,0 +1,304 @@
+// Package linttest provides a harness for testing gusano rules against
+// annotated testdata packages.
+//
+// Expected failures are declared with comments in any file of the loaded
+// packages, on the line where the failure is expected:
+//
+//	type str struct { // MATCH /unused type str/
+//	const c = 1       // want "unused const c" "another failure"
+//
+// MATCH:N /regex/ declares a failure expected at line N of the same file.
+// Every expected failure must be matched by a failure of the rule and every
+// failure of the rule must be expected.
+package linttest
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"text/scanner"
+
+	"github.com/chavacava/gusano/lint"
+)
+
+// expectation is a failure expected at a given line.
+type expectation struct {
+	filename string
+	line     int
+	pattern  *regexp.Regexp
+}
+
+// Run lints the packages matching patterns, relative to dir, with the given
+// rule and checks the failures against the annotations of the loaded files.
+// It returns the failures of the rule.
+func Run(t *testing.T, dir string, rule lint.Rule, patterns ...string) []lint.Failure {
+	t.Helper()
+	return RunWithConfig(t, dir, rule, lint.Config{}, patterns...)
+}
+
+// RunWithConfig is like Run but lints with the given configuration.
+// The rule is enabled even if config does not declare it.
+func RunWithConfig(t *testing.T, dir string, rule lint.Rule, config lint.Config, patterns ...string) []lint.Failure {
+	t.Helper()
+
+	pkgs, err := lint.LoadPackages(dir, patterns, config)
+	if err != nil {
+		t.Fatalf("cannot load packages %v from %s: %v", patterns, dir, err)
+	}
+
+	expectations := []expectation{}
+	seenFiles := map[string]bool{}
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Syntax {
+			filename := pkg.Fset.Position(file.Pos()).Filename
+			if seenFiles[filename] {
+				continue // file shared by several build configurations
+			}
+			seenFiles[filename] = true
+			for _, cg := range file.Comments {
+				for _, c := range cg.List {
+					pos := pkg.Fset.Position(c.Pos())
+					exps, err := parseAnnotation(c.Text, pos.Filename, pos.Line)
+					if err != nil {
+						t.Fatalf("%v: %v", pos, err)
+					}
+					expectations = append(expectations, exps...)
+				}
+			}
+		}
+	}
+
+	if config.Rules == nil {
+		config.Rules = lint.RulesConfig{}
+	}
+	if _, ok := config.Rules[rule.Name()]; !ok {
+		config.Rules[rule.Name()] = lint.RuleConfig{}
+	}
+
+	linter := lint.New(ioutil.ReadFile)
+	failuresChan, err := linter.Lint(pkgs, []lint.Rule{rule}, config)
+	if err != nil {
+		t.Fatalf("cannot lint packages %v: %v", patterns, err)
+	}
+	failures := []lint.Failure{}
+	for f := range failuresChan {
+		failures = append(failures, f)
+	}
+	sortFailures(failures)
+
+	unexpected := append([]lint.Failure{}, failures...)
+	for _, exp := range expectations {
+		matched := false
+		for i, f := range unexpected {
+			if f.GetFilename() == exp.filename && f.Position.Start.Line == exp.line && exp.pattern.MatchString(f.Failure) {
+				unexpected = append(unexpected[:i], unexpected[i+1:]...)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			t.Errorf("%s:%d: no failure matching /%v/", exp.filename, exp.line, exp.pattern)
+		}
+	}
+	for _, f := range unexpected {
+		t.Errorf("%v: unexpected failure: %s", f.Position.Start, f.Failure)
+	}
+
+	return failures
+}
+
+// RunWithSuggestedFixes is like RunWithConfig and also checks the suggested
+// fixes of the failures: for each file modified by the fixes, the result of
+// applying all of them must match the content of the file with the ".golden"
+// suffix added to its name.
+// A fix can also be checked alone: if the file of its failure at line N has a
+// file with the ".N.golden" suffix added to its name, the result of applying
+// only that fix must match its content.
+func RunWithSuggestedFixes(t *testing.T, dir string, rule lint.Rule, config lint.Config, patterns ...string) []lint.Failure {
+	t.Helper()
+
+	failures := RunWithConfig(t, dir, rule, config, patterns...)
+
+	edits := map[string][]lint.Edit{}
+	for _, f := range failures {
+		if f.SuggestedFix == nil {
+			continue
+		}
+		for _, e := range f.SuggestedFix.Edits {
+			edits[e.Start.Filename] = append(edits[e.Start.Filename], e)
+		}
+	}
+
+	filenames := []string{}
+	for filename := range edits {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+	for _, filename := range filenames {
+		content, err := ioutil.ReadFile(filename)
+		if err != nil {
+			t.Fatalf("cannot read %s: %v", filename, err)
+		}
+		fixed, err := ApplyEdits(content, edits[filename])
+		if err != nil {
+			t.Errorf("%s: %v", filename, err)
+			continue
+		}
+		golden, err := ioutil.ReadFile(filename + ".golden")
+		if err != nil {
+			t.Errorf("cannot read the golden file of %s: %v", filename, err)
+			continue
+		}
+		if !bytes.Equal(fixed, golden) {
+			t.Errorf("suggested fixes of %s do not match %s.golden; got:\n%s", filename, filename, fixed)
+		}
+	}
+
+	for _, f := range failures {
+		if f.SuggestedFix == nil {
+			continue
+		}
+		filename := f.Position.Start.Filename
+		golden, err := ioutil.ReadFile(fmt.Sprintf("%s.%d.golden", filename, f.Position.Start.Line))
+		if err != nil {
+			continue // no golden file for this fix alone
+		}
+		content, err := ioutil.ReadFile(filename)
+		if err != nil {
+			t.Fatalf("cannot read %s: %v", filename, err)
+		}
+		fixed, err := ApplyEdits(content, f.SuggestedFix.Edits)
+		if err != nil {
+			t.Errorf("%v: %v", f.Position.Start, err)
+			continue
+		}
+		if !bytes.Equal(fixed, golden) {
+			t.Errorf("suggested fix of %v does not match %s.%d.golden; got:\n%s", f.Position.Start, filename, f.Position.Start.Line, fixed)
+		}
+	}
+
+	return failures
+}
+
+// ApplyEdits returns the result of applying the given edits to content.
+// Edits must not overlap.
+func ApplyEdits(content []byte, edits []lint.Edit) ([]byte, error) {
+	sorted := append([]lint.Edit{}, edits...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Start.Offset != sorted[j].Start.Offset {
+			return sorted[i].Start.Offset < sorted[j].Start.Offset
+		}
+		// insertions first, and same edits next to each other
+		return sorted[i].End.Offset < sorted[j].End.Offset
+	})
+
+	var buf bytes.Buffer
+	last := 0
+	for i, e := range sorted {
+		if i > 0 && sameEdit(e, sorted[i-1]) {
+			continue // several failures can suggest the same edit
+		}
+		if e.Start.Offset < last {
+			return nil, fmt.Errorf("overlapping edits at offset %d", e.Start.Offset)
+		}
+		if e.End.Offset > len(content) || e.End.Offset < e.Start.Offset {
+			return nil, fmt.Errorf("invalid edit range [%d, %d)", e.Start.Offset, e.End.Offset)
+		}
+		buf.Write(content[last:e.Start.Offset])
+		buf.WriteString(e.NewText)
+		last = e.End.Offset
+	}
+	buf.Write(content[last:])
+
+	return buf.Bytes(), nil
+}
+
+func sameEdit(a, b lint.Edit) bool {
+	return a.Start.Offset == b.Start.Offset && a.End.Offset == b.End.Offset && a.NewText == b.NewText
+}
+
+var matchLineRE = regexp.MustCompile(`^MATCH:(\d+)\s`)
+
+// parseAnnotation returns the failures expected by the given comment.
+func parseAnnotation(comment, filename string, line int) ([]expectation, error) {
+	text := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(comment, "//"), "/*"))
+	text = strings.TrimSpace(strings.TrimSuffix(text, "*/"))
+
+	switch {
+	case strings.HasPrefix(text, "MATCH"):
+		if m := matchLineRE.FindStringSubmatch(text); m != nil {
+			n, err := strconv.Atoi(m[1])
+			if err != nil {
+				return nil, fmt.Errorf("bad line number in %q: %v", text, err)
+			}
+			line = n
+		}
+		a, b := strings.Index(text, "/"), strings.LastIndex(text, "/")
+		if a == -1 || a == b {
+			return nil, fmt.Errorf("malformed match instruction %q", text)
+		}
+		re, err := regexp.Compile(text[a+1 : b])
+		if err != nil {
+			return nil, err
+		}
+		return []expectation{{filename, line, re}}, nil
+	case strings.HasPrefix(text, "want "):
+		patterns, err := parseStrings(strings.TrimPrefix(text, "want "))
+		if err != nil {
+			return nil, fmt.Errorf("malformed want instruction %q: %v", text, err)
+		}
+		result := []expectation{}
+		for _, p := range patterns {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, expectation{filename, line, re})
+		}
+		return result, nil
+	}
+
+	return nil, nil
+}
+
+// parseStrings parses a space separated list of Go string literals.
+func parseStrings(text string) ([]string, error) {
+	var s scanner.Scanner
+	s.Init(strings.NewReader(text))
+	s.Mode = scanner.ScanStrings | scanner.ScanRawStrings
+	s.Error = func(*scanner.Scanner, string) {}
+
+	result := []string{}
+	for tok := s.Scan(); tok != scanner.EOF; tok = s.Scan() {
+		if tok != scanner.String && tok != scanner.RawString {
+			return nil, fmt.Errorf("unexpected %q", s.TokenText())
+		}
+		str, err := strconv.Unquote(s.TokenText())
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, str)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no pattern")
+	}
+	return result, nil
+}
+
+func sortFailures(failures []lint.Failure) {
+	sort.SliceStable(failures, func(i, j int) bool {
+		pi, pj := failures[i].Position.Start, failures[j].Position.Start
+		if pi.Filename != pj.Filename {
+			return pi.Filename < pj.Filename
+		}
+		if pi.Line != pj.Line {
+			return pi.Line < pj.Line
+		}
+		return pi.Column < pj.Column
+	})
+}
@@ -0,0 +1,97 @@
+package linttest
+
+import (
+	"go/token"
+	"testing"
+
+	"github.com/chavacava/gusano/lint"
+)
+
+func TestParseAnnotation(t *testing.T) {
+	tests := []struct {
+		comment  string
+		line     int
+		patterns []string
+	}{
+		{"// just a comment", 0, nil},
+		{"// MATCH /unused type str/", 10, []string{"unused type str"}},
+		{"/* MATCH /unused const c/ */", 10, []string{"unused const c"}},
+		{"// MATCH:3 /unused func f/", 3, []string{"unused func f"}},
+		{"// MATCH:42 /a/b/", 42, []string{"a/b"}},
+		{`// want "unused const c"`, 10, []string{"unused const c"}},
+		{"// want \"first\" `second \\d+`", 10, []string{"first", `second \d+`}},
+	}
+	for _, test := range tests {
+		exps, err := parseAnnotation(test.comment, "file.go", 10)
+		if err != nil {
+			t.Errorf("parseAnnotation(%q): unexpected error %v", test.comment, err)
+			continue
+		}
+		if len(exps) != len(test.patterns) {
+			t.Errorf("parseAnnotation(%q) = %d expectations, want %d", test.comment, len(exps), len(test.patterns))
+			continue
+		}
+		for i, exp := range exps {
+			if exp.filename != "file.go" || exp.line != test.line || exp.pattern.String() != test.patterns[i] {
+				t.Errorf("parseAnnotation(%q)[%d] = %s:%d /%v/, want file.go:%d /%s/", test.comment, i, exp.filename, exp.line, exp.pattern, test.line, test.patterns[i])
+			}
+		}
+	}
+}
+
+func TestParseAnnotationErrors(t *testing.T) {
+	tests := []string{
+		"// MATCH unused",
+		"// MATCH /unused",
+		"// MATCH /(/",
+		"// want unused",
+		`// want "unterminated`,
+		`// want "("`,
+	}
+	for _, comment := range tests {
+		if _, err := parseAnnotation(comment, "file.go", 1); err == nil {
+			t.Errorf("parseAnnotation(%q): expected an error", comment)
+		}
+	}
+}
+
+func edit(start, end int, text string) lint.Edit {
+	return lint.Edit{Start: token.Position{Offset: start}, End: token.Position{Offset: end}, NewText: text}
+}
+
+func TestApplyEdits(t *testing.T) {
+	tests := []struct {
+		edits []lint.Edit
+		want  string
+	}{
+		{nil, "hello world"},
+		{[]lint.Edit{edit(6, 11, "gusano")}, "hello gusano"},
+		{[]lint.Edit{edit(6, 11, "gusano"), edit(0, 5, "bye")}, "bye gusano"},
+		{[]lint.Edit{edit(5, 5, ","), edit(5, 6, "")}, "hello,world"},
+		{[]lint.Edit{edit(0, 5, "bye"), edit(0, 5, "bye")}, "bye world"},
+	}
+	for _, test := range tests {
+		got, err := ApplyEdits([]byte("hello world"), test.edits)
+		if err != nil {
+			t.Errorf("ApplyEdits(%v): unexpected error %v", test.edits, err)
+			continue
+		}
+		if string(got) != test.want {
+			t.Errorf("ApplyEdits(%v) = %q, want %q", test.edits, got, test.want)
+		}
+	}
+}
+
+func TestApplyEditsErrors(t *testing.T) {
+	tests := [][]lint.Edit{
+		{edit(0, 5, "bye"), edit(3, 8, "x")},
+		{edit(0, 5, "bye"), edit(0, 5, "hi")},
+		{edit(6, 20, "x")},
+		{edit(6, 3, "x")},
+	}
+	for _, edits := range tests {
+		if _, err := ApplyEdits([]byte("hello world"), edits); err == nil {
+			t.Errorf("ApplyEdits(%v): expected an error", edits)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package lint
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// loadMode is the information loaded for the linted packages: their typed
+// syntax, including the instances of generic declarations, and their module.
+const loadMode = packages.LoadSyntax | packages.NeedModule
+
+// LoadPackages loads the packages matching the given patterns, relative to
+// dir, under the default build configuration and under every build
+// configuration listed in config.
+// In workspace mode (go.work), patterns can span several modules of the workspace.
+func LoadPackages(dir string, patterns []string, config Config) ([]*packages.Package, error) {
+	patterns = expandWorkspacePatterns(dir, patterns)
+	// the default build configuration is always loaded
+	builds := append([]BuildConfig{{}}, config.Builds...)
+	result := []*packages.Package{}
+	for _, build := range builds {
+		pkgs, err := loadBuild(dir, patterns, build, config)
+		if err != nil {
+			return nil, fmt.Errorf("load (%v): %v", build, err)
+		}
+		result = append(result, pkgs...)
+	}
+
+	return result, nil
+}
+
+// loadBuild loads the packages matching patterns under the given build configuration.
+func loadBuild(dir string, patterns []string, build BuildConfig, config Config) ([]*packages.Package, error) {
+	cfg := &packages.Config{Mode: loadMode, Tests: config.Tests, Dir: dir}
+	if tags := append(append([]string{}, config.BuildTags...), build.Tags...); len(tags) > 0 {
+		cfg.BuildFlags = []string{"-tags=" + strings.Join(tags, ",")}
+	}
+	if build.GOOS != "" || build.GOARCH != "" {
+		cfg.Env = os.Environ()
+		if build.GOOS != "" {
+			cfg.Env = append(cfg.Env, "GOOS="+build.GOOS)
+		}
+		if build.GOARCH != "" {
+			cfg.Env = append(cfg.Env, "GOARCH="+build.GOARCH)
+		}
+	}
+
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, err
+	}
+
+	var loadErrors []string
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		for _, err := range pkg.Errors {
+			loadErrors = append(loadErrors, err.Error())
+		}
+	})
+	if len(loadErrors) > 0 {
+		return nil, errors.New(strings.Join(loadErrors, "\n"))
+	}
+
+	if config.Tests {
+		pkgs = dropTestDuplicates(pkgs)
+	}
+
+	return pkgs, nil
+}
+
+// dropTestDuplicates removes, from packages loaded with tests, the packages
+// whose files are also part of a test variant (e.g. "p" when "p [p.test]"
+// exists) and the generated test main packages.
+func dropTestDuplicates(pkgs []*packages.Package) []*packages.Package {
+	hasTestVariant := map[string]bool{}
+	for _, p := range pkgs {
+		if p.ID != p.PkgPath && strings.HasSuffix(p.ID, "["+p.PkgPath+".test]") {
+			hasTestVariant[p.PkgPath] = true
+		}
+	}
+
+	result := []*packages.Package{}
+	for _, p := range pkgs {
+		isTestMain := strings.HasSuffix(p.ID, ".test") && p.Name == "main"
+		if isTestMain || (p.ID == p.PkgPath && hasTestVariant[p.PkgPath]) {
+			continue
+		}
+		result = append(result, p)
+	}
+	return result
+}
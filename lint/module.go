@@ -0,0 +1,155 @@
+package lint
+
+import (
+	"go/ast"
+	"go/types"
+	"sort"
+	"sync"
+
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/types/objectpath"
+)
+
+// Module represents the set of packages linted together, including the
+// variants of packages loaded under several build configurations.
+type Module struct {
+	Packages []*Package
+
+	// defs and uses index the identifiers declaring and referring to exported
+	// objects, and their members, by object key
+	defs      map[string][]Ref
+	uses      map[string][]Ref
+	keys      map[types.Object]string
+	indexOnce sync.Once
+}
+
+// NewModule returns the module made of the given loaded packages, skipping
+// those without files.
+func NewModule(pkgs []*packages.Package) (*Module, error) {
+	module := &Module{}
+	for _, pkg := range pkgs {
+		rPkg, err := newPackage(pkg)
+		if err != nil {
+			return nil, err
+		}
+		if len(rPkg.files) > 0 {
+			module.Packages = append(module.Packages, rPkg)
+		}
+	}
+	return module, nil
+}
+
+// Ref is an identifier of a package of the module.
+type Ref struct {
+	Pkg   *Package
+	Ident *ast.Ident
+}
+
+// ObjectKey returns a key identifying obj across the packages of the module,
+// even when they are type-checked separately (e.g. a package and its importers).
+// It returns "" for objects that are not reachable from the scope of their
+// package, like local variables.
+func ObjectKey(obj types.Object) string {
+	if obj == nil || obj.Pkg() == nil {
+		return ""
+	}
+	path, err := objectpath.For(obj)
+	if err != nil {
+		return ""
+	}
+	return obj.Pkg().Path() + " " + string(path)
+}
+
+// Origin returns the declared object of which obj is an instance: the
+// generic method or field of a generic type, when obj belongs to an
+// instantiation of that type (e.g. List[int].Push for List[T].Push).
+// Other objects are returned as is.
+func Origin(obj types.Object) types.Object {
+	switch o := obj.(type) {
+	case *types.Func:
+		return o.Origin()
+	case *types.Var:
+		return o.Origin()
+	}
+	return obj
+}
+
+// DefsOf returns the identifiers declaring the given exported object in the
+// packages of the module, one per package variant.
+// The index is built on first call and shared by all rules.
+func (m *Module) DefsOf(obj types.Object) []Ref {
+	m.indexOnce.Do(m.buildIndex)
+	return m.defs[m.keyOf(obj)]
+}
+
+// UsesOf returns the identifiers referring to the given exported object in
+// the packages of the module, including those of other packages.
+// Uses of instances of generic declarations are uses of their origin.
+// The index is built on first call and shared by all rules.
+func (m *Module) UsesOf(obj types.Object) []Ref {
+	m.indexOnce.Do(m.buildIndex)
+	return m.uses[m.keyOf(obj)]
+}
+
+func (m *Module) keyOf(obj types.Object) string {
+	if key, ok := m.keys[obj]; ok {
+		return key
+	}
+	return ObjectKey(obj)
+}
+
+func (m *Module) buildIndex() {
+	m.defs = map[string][]Ref{}
+	m.uses = map[string][]Ref{}
+	m.keys = map[types.Object]string{}
+
+	inModule := map[string]bool{}
+	for _, p := range m.Packages {
+		if p.TypesPkg != nil {
+			inModule[p.TypesPkg.Path()] = true
+		}
+	}
+
+	key := func(obj types.Object) string {
+		if obj == nil || !obj.Exported() || obj.Pkg() == nil || !inModule[obj.Pkg().Path()] {
+			return ""
+		}
+		k, ok := m.keys[obj]
+		if !ok {
+			k = ObjectKey(obj)
+			m.keys[obj] = k
+		}
+		return k
+	}
+
+	for _, p := range m.Packages {
+		if p.TypesInfo == nil {
+			continue
+		}
+		for id, obj := range p.TypesInfo.Defs {
+			if k := key(obj); k != "" {
+				m.defs[k] = append(m.defs[k], Ref{p, id})
+			}
+		}
+		for id, obj := range p.TypesInfo.Uses {
+			if k := key(Origin(obj)); k != "" {
+				m.uses[k] = append(m.uses[k], Ref{p, id})
+			}
+		}
+	}
+
+	for _, index := range []map[string][]Ref{m.defs, m.uses} {
+		for _, refs := range index {
+			sort.Slice(refs, func(i, j int) bool {
+				pi, pj := refs[i].Pkg.fset.Position(refs[i].Ident.Pos()), refs[j].Pkg.fset.Position(refs[j].Ident.Pos())
+				if pi.Filename != pj.Filename {
+					return pi.Filename < pj.Filename
+				}
+				if pi.Offset != pj.Offset {
+					return pi.Offset < pj.Offset
+				}
+				return refs[i].Pkg.Name < refs[j].Pkg.Name
+			})
+		}
+	}
+}
@@ -5,10 +5,11 @@ import (
 	"go/ast"
 	"go/token"
 	"go/types"
-	"os"
+	"sort"
 	"sync"
 
 	gopack "golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/ssa"
 )
 
 // Package represents a package in the project.
@@ -18,18 +19,97 @@ type Package struct {
 	Name      string
 	TypesPkg  *types.Package
 	TypesInfo *types.Info
+	// ModulePath is the path of the module of the package, if any
+	ModulePath string
 
 	// sortable is the set of types in the package that implement sort.Interface.
 	Sortable map[string]bool
 	// main is whether this is a "main" package.
 	main int
 	mu   sync.Mutex
+
+	// uses is the reverse index of TypesInfo.Uses
+	uses     map[types.Object][]*ast.Ident
+	usesOnce sync.Once
+
+	ssaPkg  *ssa.Package
+	ssaOnce sync.Once
 }
 
 func (p *Package) Fset() *token.FileSet {
 	return p.fset
 }
 
+// Files returns the files of the package sorted by name.
+func (p *Package) Files() []*File {
+	result := make([]*File, 0, len(p.files))
+	for _, f := range p.files {
+		result = append(result, f)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// UsesOf returns the identifiers of the package that refer to the given object.
+// Uses of instances of generic declarations are uses of their origin.
+// The index is built on first call and shared by all rules.
+func (p *Package) UsesOf(obj types.Object) []*ast.Ident {
+	p.usesOnce.Do(p.buildUsesIndex)
+	return p.uses[obj]
+}
+
+// SSA returns the SSA form of the package, with debug information mapping
+// source expressions to SSA values.
+// It is built on first call and shared by all rules.
+func (p *Package) SSA() *ssa.Package {
+	p.ssaOnce.Do(p.buildSSA)
+	return p.ssaPkg
+}
+
+func (p *Package) buildSSA() {
+	if p.TypesPkg == nil || p.TypesInfo == nil {
+		return
+	}
+
+	prog := ssa.NewProgram(p.fset, ssa.GlobalDebug)
+	created := map[*types.Package]bool{p.TypesPkg: true}
+	var createImports func(pkgs []*types.Package)
+	createImports = func(pkgs []*types.Package) {
+		for _, imp := range pkgs {
+			if created[imp] {
+				continue
+			}
+			created[imp] = true
+			prog.CreatePackage(imp, nil, nil, true)
+			createImports(imp.Imports())
+		}
+	}
+	createImports(p.TypesPkg.Imports())
+
+	files := []*ast.File{}
+	for _, f := range p.Files() {
+		files = append(files, f.AST)
+	}
+	p.ssaPkg = prog.CreatePackage(p.TypesPkg, files, p.TypesInfo, false)
+	p.ssaPkg.Build()
+}
+
+func (p *Package) buildUsesIndex() {
+	p.uses = map[types.Object][]*ast.Ident{}
+	if p.TypesInfo == nil {
+		return
+	}
+	for id, obj := range p.TypesInfo.Uses {
+		obj = Origin(obj)
+		p.uses[obj] = append(p.uses[obj], id)
+	}
+	for _, ids := range p.uses {
+		sort.Slice(ids, func(i, j int) bool { return ids[i].Pos() < ids[j].Pos() })
+	}
+}
+
 /*
 var newImporter = func(fset *token.FileSet) types.ImporterFrom {
 	return gcexportdata.NewImporter(fset, make(map[string]*types.Package))
@@ -61,20 +141,19 @@ func (p *Package) IsMain() bool {
 // TypeCheck performs type checking for given package.
 func (p *Package) TypeCheck() error {
 	p.mu.Lock()
+	defer p.mu.Unlock()
 	// If type checking has already been performed
 	// skip it.
 	if p.TypesInfo != nil || p.TypesPkg != nil {
-		p.mu.Unlock()
 		return nil
 	}
-	cfg := &gopack.Config{Mode: gopack.LoadSyntax}
+	cfg := &gopack.Config{Mode: loadMode}
 	packages, err := gopack.Load(cfg, p.Name)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "load: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("load: %v", err)
 	}
 	if gopack.PrintErrors(packages) > 0 {
-		os.Exit(1)
+		return fmt.Errorf("errors while loading package %s", p.Name)
 	}
 
 	if len(packages) < 1 {
@@ -111,8 +190,7 @@ func (p *Package) TypeCheck() error {
 		// since we will get partial information.
 		p.TypesPkg = typesPkg
 		p.TypesInfo = info*/
-	p.mu.Unlock()
-	return err
+	return nil
 }
 
 // check function encapsulates the call to go/types.Config.Check method and
@@ -198,7 +276,7 @@ func (p *Package) lint(rules []Rule, config Config, failures chan Failure) {
 	if err != nil {
 		failures <- Failure{
 			Confidence: 1,
-			Failure:    fmt.Sprintf("Failed while type checking package %s: %v", p.TypesPkg.Name(), err),
+			Failure:    fmt.Sprintf("Failed while type checking package %s: %v", p.Name, err),
 		}
 		return
 	}
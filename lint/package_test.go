@@ -0,0 +1,90 @@
+package lint
+
+import (
+	"go/ast"
+	"go/types"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+)
+
+// loadPackage loads the given package of the testdata directory.
+func loadPackage(t *testing.T, pattern string) *Package {
+	t.Helper()
+	pkgs, err := LoadPackages("../testdata", []string{pattern}, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Package{fset: pkgs[0].Fset, TypesPkg: pkgs[0].Types, TypesInfo: pkgs[0].TypesInfo}
+}
+
+func TestPackageUsesOf(t *testing.T) {
+	for _, pattern := range []string{"./pkg1", "./pkg2", "./pkg13"} {
+		p := loadPackage(t, pattern)
+		want := map[types.Object][]*ast.Ident{}
+		for id, obj := range p.TypesInfo.Uses {
+			want[Origin(obj)] = append(want[Origin(obj)], id)
+		}
+		for _, ids := range want {
+			sort.Slice(ids, func(i, j int) bool { return ids[i].Pos() < ids[j].Pos() })
+		}
+
+		// the index is built by the first caller, the others must wait for it
+		const goroutines = 8
+		got := make([]map[types.Object][]*ast.Ident, goroutines)
+		var wg sync.WaitGroup
+		for i := range got {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				got[i] = map[types.Object][]*ast.Ident{}
+				for obj := range want {
+					got[i][obj] = p.UsesOf(obj)
+				}
+			}(i)
+		}
+		wg.Wait()
+		for i := range got {
+			if !reflect.DeepEqual(got[i], want) {
+				t.Fatalf("%s, goroutine %d: UsesOf does not match TypesInfo.Uses", pattern, i)
+			}
+		}
+	}
+}
+
+func TestPackageUsesOfInstances(t *testing.T) {
+	p := loadPackage(t, "./pkg13")
+
+	// uses of instantiated methods and fields are uses of their origin
+	list := p.TypesPkg.Scope().Lookup("list").Type().(*types.Named)
+	var push *types.Func
+	for i := 0; i < list.NumMethods(); i++ {
+		if list.Method(i).Name() == "push" {
+			push = list.Method(i)
+		}
+	}
+	node := p.TypesPkg.Scope().Lookup("node").Type().Underlying().(*types.Struct)
+	for _, test := range []struct {
+		obj  types.Object
+		uses int
+	}{
+		{push, 1},
+		{node.Field(0), 1},
+		{node.Field(1), 1},
+	} {
+		ids := p.UsesOf(test.obj)
+		if len(ids) != test.uses {
+			t.Errorf("UsesOf(%s) returned %d identifiers, want %d", test.obj.Name(), len(ids), test.uses)
+			continue
+		}
+		for _, id := range ids {
+			if p.TypesInfo.Uses[id] == test.obj {
+				t.Errorf("use of %s at %s refers to the generic declaration, want an instance", test.obj.Name(), p.Fset().Position(id.Pos()))
+			}
+		}
+	}
+	if ids := p.UsesOf(types.Universe.Lookup("len")); len(ids) != 1 {
+		t.Errorf("UsesOf(len) returned %d identifiers, want 1", len(ids))
+	}
+}
@@ -18,6 +18,33 @@ type Rule interface {
 	ApplyToPackage(*Package, Arguments, chan Failure)
 }
 
+// ConsensusRule is implemented by rules whose failures are derived from the
+// absence of evidence (e.g. the absence of uses of a symbol).
+// When a package is loaded under several build configurations, failures of
+// these rules are reported only if they are found in every configuration
+// that compiles the file of the failure.
+type ConsensusRule interface {
+	Rule
+	RequiresConsensus() bool
+}
+
+// ModuleRule is implemented by rules that need all the loaded packages at once
+// (e.g. to know whether a symbol is used outside its package).
+// ApplyToModule is called once per run, in addition to ApplyToFile and
+// ApplyToPackage.
+type ModuleRule interface {
+	Rule
+	ApplyToModule(*Module, Arguments, chan Failure)
+}
+
+// ConfigurableRule is implemented by rules with arguments.
+// CheckArguments is called once per run, before applying the rule, and
+// returns an error if the arguments are not valid.
+type ConfigurableRule interface {
+	Rule
+	CheckArguments(Arguments) error
+}
+
 // AbstractRule defines an abstract rule.
 type AbstractRule struct {
 	Failures []Failure
@@ -0,0 +1,120 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// findWorkFile returns the path of the go.work file in effect for dir, as
+// the go command does: $GOWORK if set, else the first go.work found from dir
+// up to the root. It returns "" outside of workspace mode.
+func findWorkFile(dir string) string {
+	switch gowork := os.Getenv("GOWORK"); gowork {
+	case "off":
+		return ""
+	case "":
+	default:
+		return gowork
+	}
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		path := filepath.Join(dir, "go.work")
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// workspaceModules returns the absolute directories of the modules used by
+// the go.work file in effect for dir, if any.
+func workspaceModules(dir string) []string {
+	path := findWorkFile(dir)
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	work, err := modfile.ParseWork(path, data, nil)
+	if err != nil {
+		return nil
+	}
+
+	result := []string{}
+	for _, use := range work.Use {
+		modDir := filepath.FromSlash(use.Path)
+		if !filepath.IsAbs(modDir) {
+			modDir = filepath.Join(filepath.Dir(path), modDir)
+		}
+		result = append(result, filepath.Clean(modDir))
+	}
+	return result
+}
+
+// expandWorkspacePatterns rewrites the relative "..." patterns that denote a
+// directory containing several modules of the workspace but belonging to none
+// of them (e.g. "./..." at the root of the workspace), that the go command
+// rejects, into one pattern per module under that directory.
+func expandWorkspacePatterns(dir string, patterns []string) []string {
+	modules := workspaceModules(dir)
+	if len(modules) == 0 {
+		return patterns
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return patterns
+	}
+
+	result := []string{}
+	for _, pattern := range patterns {
+		prefix := strings.TrimSuffix(pattern, "...")
+		if prefix == pattern || !strings.HasPrefix(pattern, ".") {
+			result = append(result, pattern)
+			continue
+		}
+		root := filepath.Join(absDir, filepath.FromSlash(prefix))
+
+		var under []string
+		inModule := false
+		for _, m := range modules {
+			switch {
+			case isUnder(root, m):
+				inModule = true
+			case isUnder(m, root):
+				under = append(under, m)
+			}
+		}
+		if inModule || len(under) == 0 {
+			result = append(result, pattern)
+			continue
+		}
+		for _, m := range under {
+			rel, err := filepath.Rel(absDir, m)
+			if err != nil {
+				continue
+			}
+			// the "./" prefix keeps the pattern relative to dir
+			result = append(result, "./"+filepath.ToSlash(filepath.Join(rel, "...")))
+		}
+	}
+	return result
+}
+
+// isUnder returns true if path is dir or one of its subdirectories.
+func isUnder(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
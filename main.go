@@ -1,12 +1,16 @@
 package main
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"os"
 
 	"github.com/chavacava/gusano/lint"
+	"github.com/chavacava/gusano/metrics"
 	"github.com/fatih/color"
+	"golang.org/x/tools/go/packages"
 )
 
 var logo = color.YellowString(`  __       __   _         
@@ -25,9 +29,23 @@ Example:
 `, logo, call)
 
 func main() {
-	config := getConfig()
+	flag.Parse()
+	config, origins := getConfig()
+	if printConfigFlag {
+		printConfig(config, origins)
+		os.Exit(0)
+	}
+	if command, ok := commands[flag.Arg(0)]; ok {
+		command.run(config, flag.Args()[1:])
+		os.Exit(0)
+	}
 	formatter := getFormatter()
-	packages := getPackages()
+	packages := getPackages(config, flag.Args())
+	if metricsPath != "" {
+		writeMetrics(packages)
+	}
+
+	closeOutput := redirectOutput()
 
 	gusano := lint.New(func(file string) ([]byte, error) {
 		return ioutil.ReadFile(file)
@@ -40,11 +58,50 @@ func main() {
 		fail(err.Error())
 	}
 
+	exitCode := reportFailures(config, formatter, failures)
+	closeOutput()
+	os.Exit(exitCode)
+}
+
+// writeMetrics writes the coupling and cohesion metrics of the packages to
+// the file given by the -metrics flag.
+func writeMetrics(pkgs []*packages.Package) {
+	module, err := lint.NewModule(pkgs)
+	if err != nil {
+		fail("cannot compute the metrics: " + err.Error())
+	}
+	data, err := json.MarshalIndent(metrics.Compute(module.Packages), "", "  ")
+	if err != nil {
+		fail("cannot compute the metrics: " + err.Error())
+	}
+	if err := ioutil.WriteFile(metricsPath, append(data, '\n'), 0644); err != nil {
+		fail("cannot write the metrics file: " + err.Error())
+	}
+}
+
+// redirectOutput redirects the standard output, where formatters write, to
+// the -output file if any. The returned function closes the file.
+func redirectOutput() func() {
+	if outputPath == "" {
+		return func() {}
+	}
+	out, err := os.Create(outputPath)
+	if err != nil {
+		fail("cannot create the output file: " + err.Error())
+	}
+	os.Stdout = out
+	return func() { out.Close() }
+}
+
+// reportFailures formats the failures whose confidence is high enough and
+// returns the exit code they lead to.
+func reportFailures(config *lint.Config, formatter lint.Formatter, failures <-chan lint.Failure) int {
 	formatChan := make(chan lint.Failure)
 	exitChan := make(chan bool)
 
 	var output string
 	go (func() {
+		var err error
 		output, err = formatter.Format(formatChan, *config)
 		if err != nil {
 			fail(err.Error())
@@ -60,10 +117,13 @@ func main() {
 		if exitCode == 0 {
 			exitCode = config.WarningCode
 		}
-		if c, ok := config.Rules[f.RuleName]; ok && c.Severity == lint.SeverityError {
+		if f.Severity == lint.SeverityError {
+			exitCode = config.ErrorCode
+		}
+		if c, ok := config.Rules[f.RuleName]; ok && c.Severity == lint.SeverityError && f.Severity == "" {
 			exitCode = config.ErrorCode
 		}
-		if c, ok := config.Directives[f.RuleName]; ok && c.Severity == lint.SeverityError {
+		if c, ok := config.Directives[f.RuleName]; ok && c.Severity == lint.SeverityError && f.Severity == "" {
 			exitCode = config.ErrorCode
 		}
 
@@ -75,6 +135,5 @@ func main() {
 	if output != "" {
 		fmt.Println(output)
 	}
-
-	os.Exit(exitCode)
+	return exitCode
 }
@@ -0,0 +1,229 @@
+// Package metrics computes the coupling and cohesion metrics of packages:
+// afferent and efferent coupling, instability, abstractness, distance from
+// the main sequence and the lack of cohesion of methods of their types.
+package metrics
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/chavacava/gusano/lint"
+)
+
+// Package holds the metrics of a package.
+type Package struct {
+	Path string `json:"path"`
+	// Afferent is the number of linted packages importing the package (Ca)
+	Afferent int `json:"afferent"`
+	// Efferent is the number of packages, out of the standard library, imported by the package (Ce)
+	Efferent int `json:"efferent"`
+	// Instability is Ce / (Ca + Ce): 0 for a package that only is depended
+	// upon, 1 for a package that only depends on others
+	Instability float64 `json:"instability"`
+	// Interfaces and Concretes count the package-level named types
+	Interfaces int `json:"interfaces"`
+	Concretes  int `json:"concretes"`
+	// Abstractness is the ratio of interfaces among the named types
+	Abstractness float64 `json:"abstractness"`
+	// Distance is the distance from the main sequence, |A + I - 1|
+	Distance float64 `json:"distance"`
+	// Types are the metrics of the struct types having methods
+	Types []Type `json:"types,omitempty"`
+
+	// Pkg is the package the metrics are computed from
+	Pkg *lint.Package `json:"-"`
+}
+
+// Type holds the metrics of a type.
+type Type struct {
+	Name    string `json:"name"`
+	Methods int    `json:"methods"`
+	// LCOM is the lack of cohesion of methods (LCOM4): the number of groups
+	// of methods that share no field and do not call each other.
+	// A cohesive type has an LCOM of 1.
+	LCOM int `json:"lcom"`
+
+	Pos token.Pos `json:"-"`
+}
+
+// Compute returns the metrics of the packages, sorted by path.
+// Variants of a package (build configurations, tests) are merged, and
+// external test packages are ignored.
+func Compute(pkgs []*lint.Package) []*Package {
+	byPath := map[string]*Package{}
+	imports := map[string]map[string]bool{}
+	for _, pkg := range pkgs {
+		if pkg.TypesPkg == nil || pkg.TypesInfo == nil || strings.HasSuffix(pkg.TypesPkg.Path(), "_test") {
+			continue
+		}
+		path := pkg.TypesPkg.Path()
+		if _, ok := imports[path]; !ok {
+			imports[path] = map[string]bool{}
+		}
+		for _, imp := range pkg.TypesPkg.Imports() {
+			imports[path][imp.Path()] = true
+		}
+		if _, ok := byPath[path]; !ok {
+			byPath[path] = &Package{Path: path, Pkg: pkg}
+		}
+	}
+
+	result := make([]*Package, 0, len(byPath))
+	for path, m := range byPath {
+		for imp := range imports[path] {
+			if !isStandard(imp) {
+				m.Efferent++
+			}
+		}
+		for other, imps := range imports {
+			if other != path && imps[path] {
+				m.Afferent++
+			}
+		}
+		if m.Afferent+m.Efferent > 0 {
+			m.Instability = float64(m.Efferent) / float64(m.Afferent+m.Efferent)
+		}
+
+		scope := m.Pkg.TypesPkg.Scope()
+		for _, name := range scope.Names() {
+			tn, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || tn.IsAlias() {
+				continue
+			}
+			if types.IsInterface(tn.Type()) {
+				m.Interfaces++
+			} else {
+				m.Concretes++
+			}
+		}
+		if m.Interfaces+m.Concretes > 0 {
+			m.Abstractness = float64(m.Interfaces) / float64(m.Interfaces+m.Concretes)
+		}
+		m.Distance = math.Abs(m.Abstractness + m.Instability - 1)
+		m.Types = typeMetrics(m.Pkg)
+		result = append(result, m)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Path < result[j].Path })
+	return result
+}
+
+// isStandard returns true if the package path is one of the standard library,
+// whose first element has no dot.
+func isStandard(path string) bool {
+	first := strings.SplitN(path, "/", 2)[0]
+	return !strings.Contains(first, ".")
+}
+
+// typeMetrics returns the metrics of the struct types of the package that
+// have fields and at least two methods, sorted by name.
+func typeMetrics(pkg *lint.Package) []Type {
+	info := pkg.TypesInfo
+	// methods maps the types to their methods
+	methods := map[*types.TypeName][]*types.Func{}
+	// links maps the methods to the fields they use and the methods they call
+	links := map[*types.Func][]types.Object{}
+
+	for _, file := range pkg.Files() {
+		for _, decl := range file.AST.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok || fd.Recv == nil || fd.Body == nil {
+				continue
+			}
+			fn, ok := info.Defs[fd.Name].(*types.Func)
+			if !ok {
+				continue
+			}
+			tn := receiverTypeName(fn)
+			if tn == nil {
+				continue
+			}
+			methods[tn] = append(methods[tn], fn)
+			ast.Inspect(fd.Body, func(n ast.Node) bool {
+				sel, ok := n.(*ast.SelectorExpr)
+				if !ok {
+					return true
+				}
+				if s, ok := info.Selections[sel]; ok && (s.Kind() == types.FieldVal || s.Kind() == types.MethodVal) {
+					if receiverOf(s) == tn {
+						links[fn] = append(links[fn], lint.Origin(s.Obj()))
+					}
+				}
+				return true
+			})
+		}
+	}
+
+	result := []Type{}
+	for tn, fns := range methods {
+		st, ok := tn.Type().Underlying().(*types.Struct)
+		if !ok || st.NumFields() == 0 || len(fns) < 2 {
+			continue
+		}
+		result = append(result, Type{Name: tn.Name(), Methods: len(fns), LCOM: lcom(fns, links), Pos: tn.Pos()})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// lcom returns the number of connected components of the graph whose nodes
+// are the methods, linked when they use a common field or call each other.
+func lcom(fns []*types.Func, links map[*types.Func][]types.Object) int {
+	parent := map[types.Object]types.Object{}
+	var find func(o types.Object) types.Object
+	find = func(o types.Object) types.Object {
+		p, ok := parent[o]
+		if !ok || p == o {
+			parent[o] = o
+			return o
+		}
+		root := find(p)
+		parent[o] = root
+		return root
+	}
+	for _, fn := range fns {
+		for _, linked := range links[fn] {
+			parent[find(linked)] = find(fn)
+		}
+	}
+
+	components := map[types.Object]bool{}
+	for _, fn := range fns {
+		components[find(fn)] = true
+	}
+	return len(components)
+}
+
+// receiverTypeName returns the named type of the receiver of the method.
+func receiverTypeName(fn *types.Func) *types.TypeName {
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return nil
+	}
+	return namedTypeName(recv.Type())
+}
+
+// receiverOf returns the named type declaring the selected field or method.
+func receiverOf(s *types.Selection) *types.TypeName {
+	if s.Kind() == types.MethodVal {
+		return receiverTypeName(s.Obj().(*types.Func))
+	}
+	if len(s.Index()) > 1 {
+		return nil // field of an embedded type
+	}
+	return namedTypeName(s.Recv())
+}
+
+func namedTypeName(t types.Type) *types.TypeName {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	if named, ok := t.(*types.Named); ok {
+		return named.Origin().Obj()
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/chavacava/gusano/lint"
+)
+
+// base is the path of the testdata packages of the metrics: api imports core
+// and store, store imports core.
+const base = "github.com/chavacava/gusano/testdata/pkg15"
+
+func TestCompute(t *testing.T) {
+	pkgs, err := lint.LoadPackages("../testdata", []string{"./pkg15/..."}, lint.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	module, err := lint.NewModule(pkgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Package{
+		{Path: base + "/api", Afferent: 0, Efferent: 2, Instability: 1, Distance: 0},
+		{Path: base + "/core", Afferent: 2, Efferent: 0, Instability: 0, Interfaces: 2, Concretes: 1, Abstractness: 2.0 / 3, Distance: 1.0 / 3},
+		{Path: base + "/store", Afferent: 1, Efferent: 1, Instability: 0.5, Concretes: 2, Distance: 0.5, Types: []Type{
+			{Name: "Counter", Methods: 3, LCOM: 1},
+			{Name: "Store", Methods: 4, LCOM: 2},
+		}},
+	}
+	got := Compute(module.Packages)
+	if len(got) != len(want) {
+		t.Fatalf("Compute returned %d packages, want %d", len(got), len(want))
+	}
+	for i, m := range got {
+		w := want[i]
+		if m.Path != w.Path || m.Afferent != w.Afferent || m.Efferent != w.Efferent || m.Interfaces != w.Interfaces || m.Concretes != w.Concretes {
+			t.Errorf("Compute()[%d] = %s Ca=%d Ce=%d interfaces=%d concretes=%d, want %s Ca=%d Ce=%d interfaces=%d concretes=%d",
+				i, m.Path, m.Afferent, m.Efferent, m.Interfaces, m.Concretes, w.Path, w.Afferent, w.Efferent, w.Interfaces, w.Concretes)
+		}
+		if !near(m.Instability, w.Instability) || !near(m.Abstractness, w.Abstractness) || !near(m.Distance, w.Distance) {
+			t.Errorf("%s: I=%.3f A=%.3f D=%.3f, want I=%.3f A=%.3f D=%.3f", m.Path, m.Instability, m.Abstractness, m.Distance, w.Instability, w.Abstractness, w.Distance)
+		}
+		types := []Type{}
+		for _, typ := range m.Types {
+			types = append(types, Type{Name: typ.Name, Methods: typ.Methods, LCOM: typ.LCOM})
+		}
+		if w.Types == nil {
+			w.Types = []Type{}
+		}
+		if !reflect.DeepEqual(types, w.Types) {
+			t.Errorf("%s: types = %v, want %v", m.Path, types, w.Types)
+		}
+	}
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
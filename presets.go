@@ -0,0 +1,29 @@
+package main
+
+// presets are the built-in configurations that can be referenced by name,
+// from the -config flag or from the extends list of a configuration file.
+var presets = map[string]string{
+	"recommended": `
+ignoreGeneratedHeader = false
+severity = "warning"
+confidence = 0.8
+errorCode = 0
+warningCode = 0
+
+[rule.unused-symbol]
+`,
+	"strict": `
+extends = ["recommended"]
+
+severity = "error"
+confidence = 0.0
+errorCode = 1
+warningCode = 1
+
+[rule.unused-symbol]
+severity = "error"
+
+[rule.dead-store]
+severity = "error"
+`,
+}
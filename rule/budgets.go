@@ -0,0 +1,348 @@
+package rule
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+
+	"github.com/chavacava/gusano/lint"
+)
+
+// BudgetsRule lints the packages, files, types and functions that exceed the
+// configured size and complexity budgets: files per package, lines per file,
+// exported symbols per package, methods per type, cyclomatic and cognitive
+// complexity, parameters and results of functions.
+// All the budgets are checked in a single pass over the syntax tree of each
+// file. Budgets that are not configured are not checked.
+type BudgetsRule struct{}
+
+// budgetsOptions are the options of the rule, given as a table argument:
+//
+//	[rule.budgets]
+//	arguments = [{ maxFiles = 20, maxFileLines = 800, maxExported = 40, maxMethods = 20, maxCyclomatic = 15, maxCognitive = 20, maxParams = 5, maxResults = 3 }]
+type budgetsOptions struct {
+	maxFiles      int
+	maxFileLines  int
+	maxExported   int
+	maxMethods    int
+	maxCyclomatic int
+	maxCognitive  int
+	maxParams     int
+	maxResults    int
+}
+
+func (r *BudgetsRule) parseOptions(arguments lint.Arguments) (budgetsOptions, error) {
+	options := budgetsOptions{-1, -1, -1, -1, -1, -1, -1, -1}
+	budgets := map[string]*int{
+		"maxFiles":      &options.maxFiles,
+		"maxFileLines":  &options.maxFileLines,
+		"maxExported":   &options.maxExported,
+		"maxMethods":    &options.maxMethods,
+		"maxCyclomatic": &options.maxCyclomatic,
+		"maxCognitive":  &options.maxCognitive,
+		"maxParams":     &options.maxParams,
+		"maxResults":    &options.maxResults,
+	}
+	err := parseTables(arguments, r.Name(), func(option string, value interface{}) error {
+		budget, ok := budgets[option]
+		if !ok {
+			return errUnknownOption
+		}
+		var err error
+		*budget, err = toInt(value, 0)
+		return err
+	})
+	return options, err
+}
+
+// CheckArguments checks the arguments of the rule.
+func (r *BudgetsRule) CheckArguments(arguments lint.Arguments) error {
+	_, err := r.parseOptions(arguments)
+	return err
+}
+
+// typeMethods counts the methods declared on a type of the package.
+type typeMethods struct {
+	// name is the identifier of the type declaration, or of the first method
+	// if the type is not declared in the analyzed files
+	name  *ast.Ident
+	count int
+}
+
+// ApplyToPackage applies the rule to given package.
+// Test files are not counted in the package budgets, so that all the variants
+// of a package have the same counts.
+func (r *BudgetsRule) ApplyToPackage(pkg *lint.Package, arguments lint.Arguments, failures chan lint.Failure) {
+	options, _ := r.parseOptions(arguments) // checked by CheckArguments
+	fset := pkg.Fset()
+	report := func(node ast.Node, category, format string, args ...interface{}) {
+		failures <- lint.Failure{
+			RuleName:   r.Name(),
+			Category:   category,
+			Confidence: 1,
+			Failure:    fmt.Sprintf(format, args...),
+			Node:       node,
+			Position:   lint.FailurePosition{Start: fset.Position(node.Pos()), End: fset.Position(node.End())},
+		}
+	}
+
+	files := 0
+	exported := 0
+	types := map[string]*typeMethods{}
+	for _, file := range pkg.Files() {
+		counted := !file.IsTest()
+		if counted {
+			files++
+		}
+		if lines := fset.File(file.AST.Pos()).LineCount(); options.maxFileLines >= 0 && lines > options.maxFileLines {
+			report(file.AST.Name, "size", "file has %d lines (max %d)", lines, options.maxFileLines)
+		}
+
+		for _, decl := range file.AST.Decls {
+			switch decl := decl.(type) {
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						if !counted {
+							continue
+						}
+						if spec.Name.IsExported() {
+							exported++
+						}
+						if t, ok := types[spec.Name.Name]; ok {
+							t.name = spec.Name
+						} else {
+							types[spec.Name.Name] = &typeMethods{name: spec.Name}
+						}
+					case *ast.ValueSpec:
+						for _, name := range spec.Names {
+							if counted && name.IsExported() {
+								exported++
+							}
+						}
+					}
+				}
+			case *ast.FuncDecl:
+				if counted {
+					r.countFunc(decl, types, &exported)
+				}
+				r.checkFunc(decl, options, report)
+			}
+		}
+	}
+
+	if options.maxFiles >= 0 && files > options.maxFiles {
+		report(reportedFile(pkg).Name, "size", "package %s has %d files (max %d)", pkg.TypesPkg.Path(), files, options.maxFiles)
+	}
+	if options.maxExported >= 0 && exported > options.maxExported {
+		report(reportedFile(pkg).Name, "size", "package %s exports %d symbols (max %d)", pkg.TypesPkg.Path(), exported, options.maxExported)
+	}
+	if options.maxMethods < 0 {
+		return
+	}
+	for _, name := range sortedKeys(types) {
+		if t := types[name]; t.count > options.maxMethods {
+			report(t.name, "size", "type %s has %d methods (max %d)", name, t.count, options.maxMethods)
+		}
+	}
+}
+
+// countFunc counts the function in the exported symbols of the package, or
+// in the methods of its receiver type.
+func (r *BudgetsRule) countFunc(fd *ast.FuncDecl, types map[string]*typeMethods, exported *int) {
+	if fd.Recv == nil {
+		if fd.Name.IsExported() {
+			*exported++
+		}
+		return
+	}
+	name := receiverTypeName(fd)
+	if name == "" {
+		return
+	}
+	t, ok := types[name]
+	if !ok {
+		t = &typeMethods{name: fd.Name}
+		types[name] = t
+	}
+	t.count++
+}
+
+// checkFunc checks the budgets of the function: its parameters, results and
+// complexity, computed in a single walk of its body.
+func (r *BudgetsRule) checkFunc(fd *ast.FuncDecl, options budgetsOptions, report func(ast.Node, string, string, ...interface{})) {
+	name := funcName(fd)
+	if params := fieldCount(fd.Type.Params); options.maxParams >= 0 && params > options.maxParams {
+		report(fd.Name, "size", "function %s has %d parameters (max %d)", name, params, options.maxParams)
+	}
+	if results := fieldCount(fd.Type.Results); options.maxResults >= 0 && results > options.maxResults {
+		report(fd.Name, "size", "function %s has %d results (max %d)", name, results, options.maxResults)
+	}
+	if fd.Body == nil || options.maxCyclomatic < 0 && options.maxCognitive < 0 {
+		return
+	}
+
+	c := &complexity{cyclomatic: 1}
+	ast.Walk(c, fd.Body)
+	if options.maxCyclomatic >= 0 && c.cyclomatic > options.maxCyclomatic {
+		report(fd.Name, "complexity", "function %s has a cyclomatic complexity of %d (max %d)", name, c.cyclomatic, options.maxCyclomatic)
+	}
+	if options.maxCognitive >= 0 && c.cognitive > options.maxCognitive {
+		report(fd.Name, "complexity", "function %s has a cognitive complexity of %d (max %d)", name, c.cognitive, options.maxCognitive)
+	}
+}
+
+// fieldCount returns the number of parameters or results of a field list,
+// counting each name of grouped parameters.
+func fieldCount(fields *ast.FieldList) int {
+	if fields == nil {
+		return 0
+	}
+	n := 0
+	for _, f := range fields.List {
+		if len(f.Names) == 0 {
+			n++
+		} else {
+			n += len(f.Names)
+		}
+	}
+	return n
+}
+
+// complexity computes together the cyclomatic complexity of a function (the
+// number of independent paths through it) and its cognitive complexity (how
+// hard its control flow is to follow, with increments growing with nesting).
+// The function literals are part of the function that declares them.
+type complexity struct {
+	cyclomatic int
+	cognitive  int
+	nesting    int
+}
+
+func (c *complexity) Visit(node ast.Node) ast.Visitor {
+	switch n := node.(type) {
+	case *ast.IfStmt:
+		c.cognitive += 1 + c.nesting
+		c.ifStmt(n)
+		return nil
+	case *ast.ForStmt:
+		c.cyclomatic++
+		c.cognitive += 1 + c.nesting
+		c.walk(n.Init, n.Cond, n.Post)
+		c.nested(n.Body)
+		return nil
+	case *ast.RangeStmt:
+		c.cyclomatic++
+		c.cognitive += 1 + c.nesting
+		c.walk(n.Key, n.Value, n.X)
+		c.nested(n.Body)
+		return nil
+	case *ast.SwitchStmt:
+		c.cognitive += 1 + c.nesting
+		c.walk(n.Init, n.Tag)
+		c.nested(n.Body)
+		return nil
+	case *ast.TypeSwitchStmt:
+		c.cognitive += 1 + c.nesting
+		c.walk(n.Init, n.Assign)
+		c.nested(n.Body)
+		return nil
+	case *ast.SelectStmt:
+		c.cognitive += 1 + c.nesting
+		c.nested(n.Body)
+		return nil
+	case *ast.CaseClause:
+		if n.List != nil {
+			c.cyclomatic++
+		}
+	case *ast.CommClause:
+		if n.Comm != nil {
+			c.cyclomatic++
+		}
+	case *ast.FuncLit:
+		c.nested(n.Body)
+		return nil
+	case *ast.BranchStmt:
+		if n.Label != nil || n.Tok == token.GOTO {
+			c.cognitive++
+		}
+	case *ast.BinaryExpr:
+		if n.Op != token.LAND && n.Op != token.LOR {
+			break
+		}
+		ops, operands := logicalSequence(n, nil, nil)
+		c.cyclomatic += len(ops)
+		for i, op := range ops {
+			if i == 0 || op != ops[i-1] {
+				c.cognitive++
+			}
+		}
+		for _, operand := range operands {
+			ast.Walk(c, operand)
+		}
+		return nil
+	}
+	return c
+}
+
+// ifStmt walks an if statement and its else branches, that increment the
+// cognitive complexity without nesting.
+func (c *complexity) ifStmt(n *ast.IfStmt) {
+	c.cyclomatic++
+	c.walk(n.Init, n.Cond)
+	c.nested(n.Body)
+	switch e := n.Else.(type) {
+	case *ast.IfStmt:
+		c.cognitive++
+		c.ifStmt(e)
+	case *ast.BlockStmt:
+		c.cognitive++
+		c.nested(e)
+	}
+}
+
+// walk walks the nodes that are not nil.
+func (c *complexity) walk(nodes ...ast.Node) {
+	for _, n := range nodes {
+		if n != nil {
+			ast.Walk(c, n)
+		}
+	}
+}
+
+func (c *complexity) nested(body *ast.BlockStmt) {
+	if body == nil {
+		return
+	}
+	c.nesting++
+	ast.Walk(c, body)
+	c.nesting--
+}
+
+// logicalSequence returns, from left to right, the logical operators of the
+// sequence of && and || of the expression, parentheses ignored, and the
+// operands of the sequence.
+func logicalSequence(expr ast.Expr, ops []token.Token, operands []ast.Expr) ([]token.Token, []ast.Expr) {
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		return logicalSequence(e.X, ops, operands)
+	case *ast.BinaryExpr:
+		if e.Op == token.LAND || e.Op == token.LOR {
+			ops, operands = logicalSequence(e.X, ops, operands)
+			ops = append(ops, e.Op)
+			return logicalSequence(e.Y, ops, operands)
+		}
+	}
+	return ops, append(operands, expr)
+}
+
+// ApplyToFile applies the rule to given file.
+func (r *BudgetsRule) ApplyToFile(file *lint.File, arguments lint.Arguments) []lint.Failure {
+	return nil
+}
+
+// Name returns the rule name.
+func (r *BudgetsRule) Name() string {
+	return "budgets"
+}
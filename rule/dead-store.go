@@ -0,0 +1,305 @@
+package rule
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"sort"
+
+	"github.com/chavacava/gusano/lint"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// DeadStoreRule lints package variables that are written but never read and
+// values assigned to local variables that are overwritten before being read.
+// It works on the SSA form of the package: a value assigned to a local
+// variable is dead if no read of the variable observes it.
+// Variables captured by closures or whose address is taken are not analyzed,
+// and reading a variable through the blank assignment "_ = x" counts as a read.
+type DeadStoreRule struct{}
+
+// ApplyToPackage applies the rule to given package.
+func (r *DeadStoreRule) ApplyToPackage(pkg *lint.Package, arguments lint.Arguments, failures chan lint.Failure) {
+	ssaPkg := pkg.SSA()
+	if ssaPkg == nil {
+		return
+	}
+
+	fns := packageFunctions(ssaPkg)
+	targets := assignmentTargets(pkg)
+	inMemory := allocatedVars(fns)
+
+	for _, fn := range fns {
+		if fn.Synthetic != "" {
+			continue
+		}
+		r.checkLocals(pkg, fn, targets, inMemory, failures)
+	}
+	r.checkGlobals(pkg, ssaPkg, fns, failures)
+}
+
+// packageFunctions returns the functions of the package having a body,
+// sorted by position.
+func packageFunctions(ssaPkg *ssa.Package) []*ssa.Function {
+	var fns []*ssa.Function
+	for fn := range ssautil.AllFunctions(ssaPkg.Prog) {
+		if fn.Pkg == ssaPkg && len(fn.Blocks) > 0 {
+			fns = append(fns, fn)
+		}
+	}
+	sort.Slice(fns, func(i, j int) bool { return fns[i].Pos() < fns[j].Pos() })
+	return fns
+}
+
+// allocatedVars returns the positions of the local variables of the
+// functions that live in memory.
+func allocatedVars(fns []*ssa.Function) map[token.Pos]bool {
+	inMemory := map[token.Pos]bool{}
+	for _, fn := range fns {
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				if alloc, ok := instr.(*ssa.Alloc); ok {
+					inMemory[alloc.Pos()] = true
+				}
+			}
+		}
+	}
+	return inMemory
+}
+
+// localRefs are the references to a local variable within a function.
+type localRefs struct {
+	defs  []*ssa.DebugRef
+	reads []ssa.Value
+}
+
+// deadStore is a value assigned to a local variable that is never read.
+type deadStore struct {
+	v   *types.Var
+	def *ssa.DebugRef
+}
+
+// checkLocals reports the values assigned to local variables of fn that are
+// not observed by any read of the variable.
+func (r *DeadStoreRule) checkLocals(pkg *lint.Package, fn *ssa.Function, targets map[*ast.Ident]token.Token, inMemory map[token.Pos]bool, failures chan lint.Failure) {
+	for _, store := range deadStores(fn, targets, inMemory) {
+		failures <- lint.Failure{
+			RuleName:   r.Name(),
+			Confidence: 1,
+			Failure:    fmt.Sprintf("value assigned to %s is never read", store.v.Name()),
+			Node:       store.def.Expr,
+			Position:   lint.FailurePosition{Start: pkg.Fset().Position(store.def.Expr.Pos())},
+		}
+	}
+}
+
+// deadStores returns the values assigned to local variables of fn that are
+// not observed by any read of the variable, ignoring the zero values.
+// Variables that live in memory (captured, address taken, partially assigned)
+// are ignored because their reads and writes are not tracked by the SSA values.
+func deadStores(fn *ssa.Function, targets map[*ast.Ident]token.Token, inMemory map[token.Pos]bool) []deadStore {
+	results := map[*types.Var]bool{}
+	for i := 0; i < fn.Signature.Results().Len(); i++ {
+		results[fn.Signature.Results().At(i)] = true
+	}
+
+	refs := map[*types.Var]*localRefs{}
+	var vars []*types.Var
+	for _, b := range fn.Blocks {
+		for _, instr := range b.Instrs {
+			ref, ok := instr.(*ssa.DebugRef)
+			if !ok {
+				continue
+			}
+			v, ok := ref.Object().(*types.Var)
+			if !ok || v.IsField() || v.Pkg() == nil || v.Parent() == v.Pkg().Scope() || results[v] || inMemory[v.Pos()] {
+				continue
+			}
+			vr, ok := refs[v]
+			if !ok {
+				vr = &localRefs{}
+				refs[v] = vr
+				vars = append(vars, v)
+			}
+			id, _ := ref.Expr.(*ast.Ident)
+			tok, isTarget := targets[id]
+			switch {
+			case ref.IsAddr || !isTarget:
+				vr.reads = append(vr.reads, ref.X)
+			case tok == token.ASSIGN:
+				vr.defs = append(vr.defs, ref)
+			default:
+				// compound assignments read the previous value of the
+				// variable without a debug reference to it
+				if op, ok := ref.X.(*ssa.BinOp); ok {
+					vr.defs = append(vr.defs, ref)
+					vr.reads = append(vr.reads, op.X)
+				} else {
+					vr.reads = append(vr.reads, ref.X)
+				}
+			}
+		}
+	}
+
+	var result []deadStore
+	for _, v := range vars {
+		vr := refs[v]
+		observed := map[ssa.Value]bool{}
+		for _, read := range vr.reads {
+			observe(read, observed)
+		}
+		for _, def := range vr.defs {
+			if !observed[def.X] && !isZeroConst(def.X) {
+				result = append(result, deadStore{v, def})
+			}
+		}
+	}
+	return result
+}
+
+// observe marks v, and the values it merges if it is a φ-node, as observed.
+func observe(v ssa.Value, observed map[ssa.Value]bool) {
+	if observed[v] {
+		return
+	}
+	observed[v] = true
+	if phi, ok := v.(*ssa.Phi); ok {
+		for _, edge := range phi.Edges {
+			observe(edge, observed)
+		}
+	}
+}
+
+// isZeroConst returns true if v is the zero value of its type;
+// initializing a variable to its zero value is not a dead store.
+func isZeroConst(v ssa.Value) bool {
+	c, ok := v.(*ssa.Const)
+	if !ok {
+		return false
+	}
+	if c.Value == nil {
+		return true
+	}
+	switch c.Value.Kind() {
+	case constant.Bool:
+		return !constant.BoolVal(c.Value)
+	case constant.String:
+		return constant.StringVal(c.Value) == ""
+	case constant.Int, constant.Float, constant.Complex:
+		return constant.Sign(c.Value) == 0
+	}
+	return false
+}
+
+// checkGlobals reports the unexported package variables that are assigned
+// outside of their initialization but never read.
+func (r *DeadStoreRule) checkGlobals(pkg *lint.Package, ssaPkg *ssa.Package, fns []*ssa.Function, failures chan lint.Failure) {
+	initializer := ssaPkg.Func("init")
+	written := map[*ssa.Global]bool{}
+	read := map[*ssa.Global]bool{}
+	for _, fn := range fns {
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				if _, ok := instr.(*ssa.DebugRef); ok {
+					continue
+				}
+				for _, op := range instr.Operands(nil) {
+					g, ok := (*op).(*ssa.Global)
+					if !ok || g.Pkg != ssaPkg {
+						continue
+					}
+					if store, ok := instr.(*ssa.Store); ok && store.Addr == g {
+						if fn != initializer {
+							written[g] = true
+						}
+						continue
+					}
+					read[g] = true
+				}
+			}
+		}
+	}
+
+	var globals []*ssa.Global
+	for g := range written {
+		if !read[g] && !g.Object().Exported() {
+			globals = append(globals, g)
+		}
+	}
+	sort.Slice(globals, func(i, j int) bool { return globals[i].Pos() < globals[j].Pos() })
+
+	for _, g := range globals {
+		failures <- lint.Failure{
+			RuleName:   r.Name(),
+			Confidence: 1,
+			Failure:    fmt.Sprintf("package variable %s is written but never read", g.Name()),
+			Position:   lint.FailurePosition{Start: pkg.Fset().Position(g.Pos())},
+		}
+	}
+}
+
+// assignmentTargets returns the identifiers that are assigned by an
+// assignment, a variable declaration, a range clause or an increment statement.
+// Identifiers assigned regardless of their previous value map to token.ASSIGN,
+// the others map to the operator of their compound assignment.
+func assignmentTargets(pkg *lint.Package) map[*ast.Ident]token.Token {
+	result := map[*ast.Ident]token.Token{}
+	add := func(tok token.Token, exprs ...ast.Expr) {
+		for _, e := range exprs {
+			for {
+				p, ok := e.(*ast.ParenExpr)
+				if !ok {
+					break
+				}
+				e = p.X
+			}
+			if id, ok := e.(*ast.Ident); ok {
+				result[id] = tok
+			}
+		}
+	}
+
+	for _, file := range pkg.Files() {
+		ast.Inspect(file.AST, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.AssignStmt:
+				if n.Tok == token.ASSIGN || n.Tok == token.DEFINE {
+					add(token.ASSIGN, n.Lhs...)
+				} else {
+					add(n.Tok, n.Lhs...)
+				}
+			case *ast.IncDecStmt:
+				add(n.Tok, n.X)
+			case *ast.ValueSpec:
+				if len(n.Values) > 0 {
+					for _, name := range n.Names {
+						add(token.ASSIGN, name)
+					}
+				}
+			case *ast.RangeStmt:
+				add(token.ASSIGN, n.Key, n.Value)
+			}
+			return true
+		})
+	}
+	return result
+}
+
+// ApplyToFile applies the rule to given file.
+func (r *DeadStoreRule) ApplyToFile(file *lint.File, arguments lint.Arguments) []lint.Failure {
+	return nil
+}
+
+// Name returns the rule name.
+func (r *DeadStoreRule) Name() string {
+	return "dead-store"
+}
+
+// RequiresConsensus returns true because a package variable may be read by
+// files of other build configurations.
+func (r *DeadStoreRule) RequiresConsensus() bool {
+	return true
+}
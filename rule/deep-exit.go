@@ -0,0 +1,307 @@
+package rule
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/chavacava/gusano/lint"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// DeepExitRule lints the functions that can stop the program: the functions,
+// other than main and init, that can reach a call of os.Exit or log.Fatal,
+// directly or through the functions of the module they call, and those that
+// can reach a panic that no deferred recover stops on the way.
+// Failures give the chain of calls from the function to the exit or panic.
+// Functions of test files are not reported, and functions named after the
+// MustX convention, that document that they panic, are neither reported nor
+// followed.
+type DeepExitRule struct{}
+
+// deepExitOptions are the options of the rule, given as a table argument:
+//
+//	[rule.deep-exit]
+//	arguments = [{ panics = false, ignore = ["example.com/mod/cli.fail"] }]
+type deepExitOptions struct {
+	// panics enables the analysis of the panics
+	panics bool
+	// ignore are the normalized qualified names of the functions that are
+	// allowed to stop the program, neither reported nor followed
+	ignore map[string]bool
+}
+
+func (r *DeepExitRule) parseOptions(arguments lint.Arguments) (deepExitOptions, error) {
+	options := deepExitOptions{panics: true, ignore: map[string]bool{}}
+	err := parseTables(arguments, r.Name(), func(option string, value interface{}) error {
+		var err error
+		switch option {
+		case "panics":
+			options.panics, err = toBool(value)
+		case "ignore":
+			var names []string
+			names, err = toStrings(value)
+			for _, name := range names {
+				options.ignore[normalizeSymbol(name)] = true
+			}
+		default:
+			return errUnknownOption
+		}
+		return err
+	})
+	return options, err
+}
+
+// CheckArguments checks the arguments of the rule.
+func (r *DeepExitRule) CheckArguments(arguments lint.Arguments) error {
+	_, err := r.parseOptions(arguments)
+	return err
+}
+
+// exitFuncs are the functions stopping the program.
+var exitFuncs = map[string]bool{
+	"os.Exit": true, "syscall.Exit": true,
+	"log.Fatal": true, "log.Fatalf": true, "log.Fatalln": true,
+	"log.Logger.Fatal": true, "log.Logger.Fatalf": true, "log.Logger.Fatalln": true,
+}
+
+// panicFuncs are the functions panicking, in addition to the panic builtin.
+var panicFuncs = map[string]bool{
+	"log.Panic": true, "log.Panicf": true, "log.Panicln": true,
+	"log.Logger.Panic": true, "log.Logger.Panicf": true, "log.Logger.Panicln": true,
+}
+
+// stopKind is a way for a function to stop the program.
+type stopKind int
+
+const (
+	stopExit stopKind = iota
+	stopPanic
+)
+
+// stopCall is a call that stops the program, or that leads to a function
+// that can.
+type stopCall struct {
+	call *ast.CallExpr
+	// to is the qualified name of the called function, or "" for the calls
+	// of the functions stopping the program
+	to string
+	// name is the name of the called function
+	name string
+}
+
+// callNode is a function of the call graph of the module.
+type callNode struct {
+	name string
+	pkg  *lint.Package
+	// reported is false for main, init and the functions of test files
+	reported bool
+	// ignored is true for the functions allowed to stop the program
+	ignored bool
+	calls   []stopCall
+	// stops are the first calls of the function stopping the program, by kind
+	stops map[stopKind]stopCall
+	// recovers is true if the function calls recover, or defers a call to a
+	// function of the module calling it
+	recovers     bool
+	callsRecover bool
+	deferred     []string
+}
+
+// ApplyToModule applies the rule to the call graph of the module.
+func (r *DeepExitRule) ApplyToModule(module *lint.Module, arguments lint.Arguments, failures chan lint.Failure) {
+	options, _ := r.parseOptions(arguments) // checked by CheckArguments
+
+	nodes := map[string]*callNode{}
+	for _, pkg := range module.Packages {
+		if pkg.TypesPkg == nil || pkg.TypesInfo == nil {
+			continue
+		}
+		for _, file := range pkg.Files() {
+			for _, decl := range file.AST.Decls {
+				fd, ok := decl.(*ast.FuncDecl)
+				if !ok || fd.Body == nil {
+					continue
+				}
+				obj, ok := pkg.TypesInfo.Defs[fd.Name].(*types.Func)
+				if !ok {
+					continue
+				}
+				key := qualifiedName(obj)
+				if nodes[key] != nil {
+					continue // already seen in another variant
+				}
+				nodes[key] = r.newNode(pkg, file, fd, obj, options)
+			}
+		}
+	}
+	for _, n := range nodes {
+		n.recovers = n.callsRecover
+		for _, key := range n.deferred {
+			if d := nodes[key]; d != nil && d.callsRecover {
+				n.recovers = true
+			}
+		}
+	}
+
+	kinds := []stopKind{stopExit}
+	if options.panics {
+		kinds = append(kinds, stopPanic)
+	}
+	keys := sortedKeys(nodes)
+	for _, kind := range kinds {
+		next := stopChains(nodes, keys, kind)
+		for _, key := range keys {
+			n := nodes[key]
+			hop, ok := next[key]
+			if !ok || !n.reported || n.ignored {
+				continue
+			}
+			chain := []string{n.name}
+			for k := key; ; {
+				if next[k].to == "" {
+					chain = append(chain, next[k].name)
+					break
+				}
+				k = next[k].to
+				chain = append(chain, nodes[k].name)
+			}
+			what := "stop the program"
+			if kind == stopPanic {
+				what = "panic"
+			}
+			failures <- lint.Failure{
+				RuleName:   r.Name(),
+				Category:   "bad practice",
+				Confidence: 1,
+				Failure:    fmt.Sprintf("function %s can %s: %s", n.name, what, strings.Join(chain, " -> ")),
+				Node:       hop.call,
+				Position:   lint.FailurePosition{Start: n.pkg.Fset().Position(hop.call.Pos()), End: n.pkg.Fset().Position(hop.call.End())},
+			}
+		}
+	}
+}
+
+// isMustName reports whether name follows the MustX convention of the
+// functions documented to panic: Must, or Must followed by an upper-case
+// letter (MustCompile but not Mustache).
+func isMustName(name string) bool {
+	if !strings.HasPrefix(name, "Must") {
+		return false
+	}
+	next, _ := utf8.DecodeRuneInString(name[len("Must"):])
+	return next == utf8.RuneError || unicode.IsUpper(next)
+}
+
+// newNode returns the node of the function, with its calls.
+// The calls of the function literals of the function are calls of the
+// function.
+func (r *DeepExitRule) newNode(pkg *lint.Package, file *lint.File, fd *ast.FuncDecl, obj *types.Func, options deepExitOptions) *callNode {
+	n := &callNode{
+		name:     pkg.TypesPkg.Name() + "." + funcName(fd),
+		pkg:      pkg,
+		reported: !file.IsTest() && !(fd.Recv == nil && (fd.Name.Name == "main" || fd.Name.Name == "init")),
+		ignored:  isMustName(fd.Name.Name) || options.ignore[qualifiedName(obj)],
+		stops:    map[stopKind]stopCall{},
+	}
+	deferred := map[*ast.CallExpr]bool{}
+	ast.Inspect(fd.Body, func(node ast.Node) bool {
+		if d, ok := node.(*ast.DeferStmt); ok {
+			deferred[d.Call] = true
+		}
+		call, ok := node.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		switch callee := typeutil.Callee(pkg.TypesInfo, call).(type) {
+		case *types.Builtin:
+			switch callee.Name() {
+			case "panic":
+				n.addStop(stopPanic, stopCall{call: call, name: "panic"})
+			case "recover":
+				n.callsRecover = true
+			}
+		case *types.Func:
+			callee = callee.Origin()
+			name := qualifiedName(callee)
+			switch {
+			case exitFuncs[name]:
+				n.addStop(stopExit, stopCall{call: call, name: callee.FullName()})
+			case panicFuncs[name]:
+				n.addStop(stopPanic, stopCall{call: call, name: callee.FullName()})
+			case typeutil.StaticCallee(pkg.TypesInfo, call) != nil:
+				key := qualifiedName(callee)
+				n.calls = append(n.calls, stopCall{call: call, to: key})
+				if deferred[call] {
+					n.deferred = append(n.deferred, key)
+				}
+			}
+		}
+		return true
+	})
+	return n
+}
+
+func (n *callNode) addStop(kind stopKind, call stopCall) {
+	if _, ok := n.stops[kind]; !ok {
+		n.stops[kind] = call
+	}
+}
+
+// stopChains returns, for each function that can stop the program in the
+// given way, its call leading to the nearest stop: a breadth-first search
+// from the functions stopping the program to their callers.
+// Panics do not go through the functions that recover.
+func stopChains(nodes map[string]*callNode, keys []string, kind stopKind) map[string]stopCall {
+	type callerEdge struct {
+		from string
+		call stopCall
+	}
+	callers := map[string][]callerEdge{}
+	for _, key := range keys {
+		for _, c := range nodes[key].calls {
+			callers[c.to] = append(callers[c.to], callerEdge{key, c})
+		}
+	}
+
+	blocks := func(n *callNode) bool {
+		return n.ignored || kind == stopPanic && n.recovers
+	}
+	next := map[string]stopCall{}
+	var queue []string
+	for _, key := range keys {
+		if stop, ok := nodes[key].stops[kind]; ok && !blocks(nodes[key]) {
+			next[key] = stop
+			queue = append(queue, key)
+		}
+	}
+	for len(queue) > 0 {
+		key := queue[0]
+		queue = queue[1:]
+		for _, e := range callers[key] {
+			if _, seen := next[e.from]; seen || blocks(nodes[e.from]) {
+				continue
+			}
+			next[e.from] = e.call
+			queue = append(queue, e.from)
+		}
+	}
+	return next
+}
+
+// ApplyToPackage applies the rule to given package.
+func (r *DeepExitRule) ApplyToPackage(pkg *lint.Package, arguments lint.Arguments, failures chan lint.Failure) {
+}
+
+// ApplyToFile applies the rule to given file.
+func (r *DeepExitRule) ApplyToFile(file *lint.File, arguments lint.Arguments) []lint.Failure {
+	return nil
+}
+
+// Name returns the rule name.
+func (r *DeepExitRule) Name() string {
+	return "deep-exit"
+}
@@ -0,0 +1,213 @@
+package rule
+
+import (
+	"go/ast"
+	"go/types"
+	"regexp"
+	"strings"
+
+	"github.com/chavacava/gusano/lint"
+)
+
+// keepDirective is the comment marking a declaration as used from outside of
+// the Go type system.
+const keepDirective = "//gusano:keep"
+
+var (
+	generateRE   = regexp.MustCompile(`^//go:generate\s+\S+\s+(.*)$`)
+	identifierRE = regexp.MustCompile(`^[\pL_][\pL\pN_]*$`)
+)
+
+// generateNameFlags are the flags of code generators whose values name
+// declarations of the package (e.g. stringer -type=T,U).
+var generateNameFlags = map[string]bool{"type": true, "types": true, "func": true, "funcs": true, "name": true, "names": true}
+
+// generateNames returns the names of the declarations given in the arguments
+// of a //go:generate directive: the values of the flags naming declarations,
+// given as -type=T or -type T, and the identifiers given as arguments of the
+// command.
+// The leading words naming the command (e.g. run in go run gen.go), flag
+// names, the values of the other flags and file names are ignored.
+func generateNames(args string) []string {
+	var result []string
+	fields := strings.Fields(args)
+	command := true
+	for i := 0; i < len(fields); i++ {
+		arg := fields[i]
+		if !strings.HasPrefix(arg, "-") {
+			isIdent := identifierRE.MatchString(arg)
+			if isIdent && !command {
+				result = append(result, arg)
+			}
+			command = command && isIdent
+			continue
+		}
+		command = false
+		flag, value, ok := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !ok && i+1 < len(fields) && !strings.HasPrefix(fields[i+1], "-") {
+			i++
+			value = fields[i]
+		}
+		if !generateNameFlags[flag] {
+			continue
+		}
+		for _, name := range strings.Split(value, ",") {
+			if identifierRE.MatchString(name) {
+				result = append(result, name)
+			}
+		}
+	}
+	return result
+}
+
+// directiveRoots returns the declarations of the package that are used from
+// outside of the Go type system, thus can not be analyzed from references:
+//   - functions exported to C with a cgo //export directive,
+//   - local names of //go:linkname directives,
+//   - package-level declarations named by the flags (e.g. stringer -type=T) or
+//     the arguments of the command of //go:generate directives,
+//   - functions and methods registered in a text/template or html/template FuncMap,
+//   - exported package-level functions and variables of plugins (main packages without a main function),
+//   - declarations annotated with a //gusano:keep comment.
+func directiveRoots(pkg *lint.Package) map[types.Object]bool {
+	result := map[types.Object]bool{}
+	scope := pkg.TypesPkg.Scope()
+
+	if pkg.IsMain() && scope.Lookup("main") == nil {
+		for _, name := range scope.Names() {
+			switch obj := scope.Lookup(name).(type) {
+			case *types.Func, *types.Var:
+				if obj.Exported() {
+					result[obj] = true
+				}
+			}
+		}
+	}
+
+	for _, file := range pkg.Files() {
+		for _, cg := range file.AST.Comments {
+			for _, c := range cg.List {
+				commentRoots(c.Text, scope, result)
+			}
+		}
+		ast.Inspect(file.AST, func(n ast.Node) bool {
+			declRoots(pkg, n, result)
+			return true
+		})
+	}
+	return result
+}
+
+// commentRoots adds to result the declarations named by the //go:linkname or
+// //go:generate directive of the comment, if any.
+func commentRoots(comment string, scope *types.Scope, result map[types.Object]bool) {
+	if m := linknameRE.FindStringSubmatch(comment); m != nil {
+		if obj := scope.Lookup(m[1]); obj != nil {
+			result[obj] = true
+		}
+	}
+	if m := generateRE.FindStringSubmatch(comment); m != nil {
+		for _, name := range generateNames(m[1]) {
+			if obj := scope.Lookup(name); obj != nil {
+				result[obj] = true
+			}
+		}
+	}
+}
+
+// declRoots adds to result the declarations of the node that are roots: cgo
+// exported functions, declarations with a //gusano:keep comment and functions
+// registered in a FuncMap.
+func declRoots(pkg *lint.Package, node ast.Node, result map[types.Object]bool) {
+	info := pkg.TypesInfo
+	keep := func(ids []*ast.Ident, groups ...*ast.CommentGroup) {
+		if !hasKeepDirective(groups...) {
+			return
+		}
+		for _, id := range ids {
+			if obj := info.Defs[id]; obj != nil {
+				result[obj] = true
+			}
+		}
+	}
+	switch n := node.(type) {
+	case *ast.FuncDecl:
+		if obj := info.Defs[n.Name]; obj != nil && (isCgoExported(n) || hasKeepDirective(n.Doc)) {
+			result[obj] = true
+		}
+	case *ast.GenDecl:
+		for _, spec := range n.Specs {
+			switch s := spec.(type) {
+			case *ast.TypeSpec:
+				keep([]*ast.Ident{s.Name}, n.Doc, s.Doc, s.Comment)
+			case *ast.ValueSpec:
+				keep(s.Names, n.Doc, s.Doc, s.Comment)
+			}
+		}
+	case *ast.Field:
+		keep(n.Names, n.Doc, n.Comment)
+	case *ast.CompositeLit:
+		if !isFuncMap(info.TypeOf(n)) {
+			return
+		}
+		for _, elt := range n.Elts {
+			if kv, ok := elt.(*ast.KeyValueExpr); ok {
+				if obj := referredFunc(info, kv.Value); obj != nil && obj.Pkg() == pkg.TypesPkg {
+					result[obj] = true
+				}
+			}
+		}
+	}
+}
+
+// hasKeepDirective returns true if one of the comment groups has a
+// //gusano:keep comment.
+func hasKeepDirective(groups ...*ast.CommentGroup) bool {
+	for _, cg := range groups {
+		if cg == nil {
+			continue
+		}
+		for _, c := range cg.List {
+			if c.Text == keepDirective || strings.HasPrefix(c.Text, keepDirective+" ") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isFuncMap returns true if t is the FuncMap type of text/template or html/template.
+func isFuncMap(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil || named.Obj().Name() != "FuncMap" {
+		return false
+	}
+	path := named.Obj().Pkg().Path()
+	return path == "text/template" || path == "html/template"
+}
+
+// referredFunc returns the function or method that expr refers to, if any
+// (e.g. f, pkg.F, T.method or v.method).
+func referredFunc(info *types.Info, expr ast.Expr) types.Object {
+	for {
+		paren, ok := expr.(*ast.ParenExpr)
+		if !ok {
+			break
+		}
+		expr = paren.X
+	}
+	var id *ast.Ident
+	switch e := expr.(type) {
+	case *ast.Ident:
+		id = e
+	case *ast.SelectorExpr:
+		id = e.Sel
+	default:
+		return nil
+	}
+	fn, ok := info.Uses[id].(*types.Func)
+	if !ok {
+		return nil
+	}
+	return fn
+}
@@ -0,0 +1,26 @@
+package rule
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGenerateNames(t *testing.T) {
+	tests := []struct {
+		args string
+		want []string
+	}{
+		{"-type=Color -output=color_string.go", []string{"Color"}},
+		{"-type Color,Shade", []string{"Color", "Shade"}},
+		{"-source=store.go -destination=mock.go", nil},
+		{"run gen.go -func generated", []string{"generated"}},
+		{"tool stringer -linecomment=true -type=Color", []string{"Color"}},
+		{"run gen.go Color", []string{"Color"}},
+		{"-output out.go Color", []string{"Color"}},
+	}
+	for _, test := range tests {
+		if got := generateNames(test.args); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("generateNames(%q) = %q, want %q", test.args, got, test.want)
+		}
+	}
+}
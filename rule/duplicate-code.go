@@ -0,0 +1,337 @@
+package rule
+
+import (
+	"encoding/binary"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"hash/fnv"
+	"sort"
+	"strings"
+
+	"github.com/chavacava/gusano/lint"
+)
+
+// DuplicateCodeRule lints the code copied and pasted across the module:
+// sequences of statements, including whole function bodies, that are the
+// same once identifiers and literals are abstracted away.
+// Each group of clones is reported once, at its first copy, with the other
+// copies as related positions.
+type DuplicateCodeRule struct{}
+
+// duplicateCodeOptions are the options of the rule, given as a table argument:
+//
+//	[rule.duplicate-code]
+//	arguments = [{ minTokens = 80 }]
+type duplicateCodeOptions struct {
+	// minTokens is the minimum size of the reported clones
+	minTokens int
+}
+
+func (r *DuplicateCodeRule) parseOptions(arguments lint.Arguments) (duplicateCodeOptions, error) {
+	options := duplicateCodeOptions{minTokens: 60}
+	err := parseTables(arguments, r.Name(), func(option string, value interface{}) error {
+		var err error
+		switch option {
+		case "minTokens":
+			options.minTokens, err = toInt(value, 1)
+		default:
+			return errUnknownOption
+		}
+		return err
+	})
+	return options, err
+}
+
+// CheckArguments checks the arguments of the rule.
+func (r *DuplicateCodeRule) CheckArguments(arguments lint.Arguments) error {
+	_, err := r.parseOptions(arguments)
+	return err
+}
+
+// clone is a copy of duplicated code: a sequence of statements of a block.
+type clone struct {
+	start, end token.Position
+	// fn is the name of the function holding the statements
+	fn     string
+	tokens int
+	// shape is the normalized syntax tree of each statement
+	shape []string
+}
+
+// sameShape returns true if the clones are the same sequence of normalized
+// statements, and not only the same hash.
+func (c clone) sameShape(other clone) bool {
+	if len(c.shape) != len(other.shape) {
+		return false
+	}
+	for i := range c.shape {
+		if c.shape[i] != other.shape[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (c clone) contains(other clone) bool {
+	return c.start.Filename == other.start.Filename && c.start.Offset <= other.start.Offset && other.end.Offset <= c.end.Offset
+}
+
+func (c clone) overlaps(other clone) bool {
+	return c.start.Filename == other.start.Filename && c.start.Offset < other.end.Offset && other.start.Offset < c.end.Offset
+}
+
+// ApplyToModule applies the rule to the files of the module.
+func (r *DuplicateCodeRule) ApplyToModule(module *lint.Module, arguments lint.Arguments, failures chan lint.Failure) {
+	options, _ := r.parseOptions(arguments) // checked by CheckArguments
+
+	groups := map[uint64][]clone{}
+	seenFiles := map[string]bool{}
+	for _, pkg := range module.Packages {
+		for _, file := range pkg.Files() {
+			if seenFiles[file.Name] {
+				continue // file shared by several package variants
+			}
+			seenFiles[file.Name] = true
+			for _, decl := range file.AST.Decls {
+				fd, ok := decl.(*ast.FuncDecl)
+				if !ok || fd.Body == nil {
+					continue
+				}
+				fn := pkg.TypesPkg.Name() + "." + funcName(fd)
+				ast.Inspect(fd.Body, func(n ast.Node) bool {
+					var stmts []ast.Stmt
+					switch n := n.(type) {
+					case *ast.BlockStmt:
+						stmts = n.List
+					case *ast.CaseClause:
+						stmts = n.Body
+					case *ast.CommClause:
+						stmts = n.Body
+					}
+					for hash, c := range sequences(pkg, stmts, fn, options.minTokens) {
+						groups[hash] = append(groups[hash], c...)
+					}
+					return true
+				})
+			}
+		}
+	}
+
+	for _, group := range cloneGroups(groups) {
+		r.report(group, failures)
+	}
+}
+
+// funcName returns the name of the function, qualified by the receiver type
+// for methods.
+func funcName(fd *ast.FuncDecl) string {
+	if recv := receiverTypeName(fd); recv != "" {
+		return recv + "." + fd.Name.Name
+	}
+	return fd.Name.Name
+}
+
+// sequences returns, by hash, the sequences of consecutive statements of the
+// list whose size is at least minTokens. The clauses of switch and select
+// statements are not sequences, their bodies are.
+func sequences(pkg *lint.Package, stmts []ast.Stmt, fn string, minTokens int) map[uint64][]clone {
+	if len(stmts) > 0 {
+		switch stmts[0].(type) {
+		case *ast.CaseClause, *ast.CommClause:
+			return nil
+		}
+	}
+	shapes := make([]string, len(stmts))
+	hashes := make([]uint64, len(stmts))
+	sizes := make([]int, len(stmts))
+	for i, stmt := range stmts {
+		shapes[i], sizes[i] = normalize(stmt)
+		h := fnv.New64a()
+		h.Write([]byte(shapes[i]))
+		hashes[i] = h.Sum64()
+	}
+
+	result := map[uint64][]clone{}
+	buf := make([]byte, 8)
+	for i := range stmts {
+		h := fnv.New64a()
+		tokens := 0
+		for j := i; j < len(stmts); j++ {
+			binary.LittleEndian.PutUint64(buf, hashes[j])
+			h.Write(buf)
+			tokens += sizes[j]
+			if tokens < minTokens {
+				continue
+			}
+			hash := h.Sum64()
+			result[hash] = append(result[hash], clone{
+				start:  pkg.Fset().Position(stmts[i].Pos()),
+				end:    pkg.Fset().Position(stmts[j].End()),
+				fn:     fn,
+				tokens: tokens,
+				shape:  shapes[i : j+1],
+			})
+		}
+	}
+	return result
+}
+
+// normalize returns the syntax tree of the node, with the identifiers and
+// literals abstracted, and its size in tokens: identifiers, literals,
+// operators and keywords.
+func normalize(node ast.Node) (string, int) {
+	b := &strings.Builder{}
+	tokens := 0
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case nil:
+			b.WriteByte(')')
+			return true
+		case *ast.Ident:
+			b.WriteString("(i")
+		case *ast.BasicLit:
+			b.WriteString("(l" + n.Kind.String())
+		case *ast.BinaryExpr:
+			b.WriteString("(b" + n.Op.String())
+		case *ast.UnaryExpr:
+			b.WriteString("(u" + n.Op.String())
+		case *ast.AssignStmt:
+			b.WriteString("(a" + n.Tok.String())
+		case *ast.IncDecStmt:
+			b.WriteString("(x" + n.Tok.String())
+		case *ast.BranchStmt:
+			b.WriteString("(k" + n.Tok.String())
+		case *ast.BlockStmt, *ast.ExprStmt, *ast.FieldList, *ast.ParenExpr:
+			// structure only
+			b.WriteString(fmt.Sprintf("(%T", n))
+			return true
+		default:
+			b.WriteString(fmt.Sprintf("(%T", n))
+		}
+		tokens++
+		return true
+	})
+	return b.String(), tokens
+}
+
+// cloneGroups returns the groups of clones to report, largest first: the
+// groups of at least two copies that do not overlap, and that are not
+// contained in the copies of a larger group.
+// Clones with the same hash are compared, to split the groups of clones that
+// only share their hash.
+func cloneGroups(groups map[uint64][]clone) [][]clone {
+	candidates := [][]clone{}
+	for _, group := range sameShapes(groups) {
+		sort.Slice(group, func(i, j int) bool { return positionLess(group[i].start, group[j].start) })
+		disjoint := []clone{}
+		for _, c := range group {
+			if len(disjoint) == 0 || !disjoint[len(disjoint)-1].overlaps(c) {
+				disjoint = append(disjoint, c)
+			}
+		}
+		if len(disjoint) > 1 {
+			candidates = append(candidates, disjoint)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i][0].tokens != candidates[j][0].tokens {
+			return candidates[i][0].tokens > candidates[j][0].tokens
+		}
+		return positionLess(candidates[i][0].start, candidates[j][0].start)
+	})
+
+	result := [][]clone{}
+	reported := []clone{}
+	for _, group := range candidates {
+		covered := true
+		for _, c := range group {
+			inside := false
+			for _, r := range reported {
+				if r.contains(c) {
+					inside = true
+					break
+				}
+			}
+			if !inside {
+				covered = false
+				break
+			}
+		}
+		if covered {
+			continue
+		}
+		result = append(result, group)
+		reported = append(reported, group...)
+	}
+	return result
+}
+
+// sameShapes splits the groups of clones with the same hash into groups of
+// clones with the same normalized statements.
+func sameShapes(groups map[uint64][]clone) [][]clone {
+	result := [][]clone{}
+	for _, group := range groups {
+		var shapes [][]clone
+		for _, c := range group {
+			found := false
+			for i, s := range shapes {
+				if s[0].sameShape(c) {
+					shapes[i] = append(s, c)
+					found = true
+					break
+				}
+			}
+			if !found {
+				shapes = append(shapes, []clone{c})
+			}
+		}
+		result = append(result, shapes...)
+	}
+	return result
+}
+
+func positionLess(a, b token.Position) bool {
+	if a.Filename != b.Filename {
+		return a.Filename < b.Filename
+	}
+	return a.Offset < b.Offset
+}
+
+func (r *DuplicateCodeRule) report(group []clone, failures chan lint.Failure) {
+	fns := []string{}
+	seen := map[string]bool{}
+	related := []lint.FailurePosition{}
+	for i, c := range group {
+		if !seen[c.fn] {
+			seen[c.fn] = true
+			fns = append(fns, c.fn)
+		}
+		if i > 0 {
+			related = append(related, lint.FailurePosition{Start: c.start, End: c.end})
+		}
+	}
+	first := group[0]
+	failures <- lint.Failure{
+		RuleName:   r.Name(),
+		Category:   "duplication",
+		Confidence: 1,
+		Failure:    fmt.Sprintf("duplicated code: %d copies of %d tokens, in %s", len(group), first.tokens, strings.Join(fns, ", ")),
+		Position:   lint.FailurePosition{Start: first.start, End: first.end},
+		Related:    related,
+	}
+}
+
+// ApplyToPackage applies the rule to given package.
+func (r *DuplicateCodeRule) ApplyToPackage(pkg *lint.Package, arguments lint.Arguments, failures chan lint.Failure) {
+}
+
+// ApplyToFile applies the rule to given file.
+func (r *DuplicateCodeRule) ApplyToFile(file *lint.File, arguments lint.Arguments) []lint.Failure {
+	return nil
+}
+
+// Name returns the rule name.
+func (r *DuplicateCodeRule) Name() string {
+	return "duplicate-code"
+}
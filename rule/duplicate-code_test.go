@@ -0,0 +1,25 @@
+package rule
+
+import (
+	"go/token"
+	"testing"
+)
+
+func TestCloneGroupsCompareShapes(t *testing.T) {
+	at := func(file string, offset int) token.Position {
+		return token.Position{Filename: file, Offset: offset}
+	}
+	// the clones of a.go and b.go share their hash with the clone of c.go,
+	// as if the hashes of their statements collided
+	groups := map[uint64][]clone{
+		1: {
+			{start: at("a.go", 0), end: at("a.go", 100), fn: "a.F", tokens: 70, shape: []string{"(x", "(y"}},
+			{start: at("c.go", 0), end: at("c.go", 100), fn: "c.H", tokens: 70, shape: []string{"(x", "(z"}},
+			{start: at("b.go", 0), end: at("b.go", 100), fn: "b.G", tokens: 70, shape: []string{"(x", "(y"}},
+		},
+	}
+	result := cloneGroups(groups)
+	if len(result) != 1 || len(result[0]) != 2 || result[0][0].fn != "a.F" || result[0][1].fn != "b.G" {
+		t.Errorf("cloneGroups() = %+v, want the group of a.F and b.G", result)
+	}
+}
@@ -0,0 +1,444 @@
+package rule
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+
+	"github.com/chavacava/gusano/lint"
+)
+
+// ForbiddenAPIRule lints the uses of forbidden packages, functions, methods,
+// fields, variables, constants and types. Uses are matched with the objects
+// they refer to, not by their text: renamed imports, method values and
+// promoted methods are found too.
+// When the replacement of a forbidden function is a function with the same
+// signature, the failure comes with a suggested fix.
+type ForbiddenAPIRule struct{}
+
+// forbiddenAPI is a forbidden package or symbol, given as a table argument:
+//
+//	[rule.forbidden-api]
+//	arguments = [
+//	  { package = "crypto/md5", message = "MD5 is broken" },
+//	  { symbol = "time.Now", message = "time must be mockable", replacement = "example.com/mod/clock.Now", except = ["example.com/mod/clock"] },
+//	  { symbol = "(*sync.Mutex).Lock", message = "handlers must not block", only = ["example.com/mod/handlers/..."] },
+//	]
+type forbiddenAPI struct {
+	// pkg is the pattern of the forbidden packages
+	pkg string
+	// symbol is the forbidden symbol as <package path>.<name>, the name
+	// being qualified by the type for methods and fields
+	symbol      string
+	message     string
+	replacement string
+	// only, if not empty, lists the patterns of the packages where the API is forbidden
+	only []string
+	// except lists the patterns of the packages where the API is allowed
+	except []string
+}
+
+func (r *ForbiddenAPIRule) parseOptions(arguments lint.Arguments) ([]forbiddenAPI, error) {
+	result := []forbiddenAPI{}
+	for _, arg := range arguments {
+		api := forbiddenAPI{}
+		err := parseTable(arg, r.Name(), func(option string, value interface{}) error {
+			var err error
+			switch option {
+			case "package", "symbol", "message", "replacement":
+				s, ok := value.(string)
+				if !ok || s == "" {
+					return errors.New("expected a non-empty string")
+				}
+				switch option {
+				case "package":
+					api.pkg = s
+				case "symbol":
+					api.symbol = normalizeSymbol(s)
+				case "message":
+					api.message = s
+				default:
+					api.replacement = s
+				}
+			case "only":
+				api.only, err = toStrings(value)
+			case "except":
+				api.except, err = toStrings(value)
+			default:
+				return errUnknownOption
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		if (api.pkg == "") == (api.symbol == "") {
+			return nil, fmt.Errorf("invalid argument %v for %s rule, expected either a package or a symbol", arg, r.Name())
+		}
+		result = append(result, api)
+	}
+	return result, nil
+}
+
+// CheckArguments checks the arguments of the rule.
+func (r *ForbiddenAPIRule) CheckArguments(arguments lint.Arguments) error {
+	_, err := r.parseOptions(arguments)
+	return err
+}
+
+// normalizeSymbol returns the symbol without the parentheses and the star of
+// the receiver of methods: (*sync.Mutex).Lock is sync.Mutex.Lock.
+func normalizeSymbol(symbol string) string {
+	if strings.HasPrefix(symbol, "(") {
+		if end := strings.Index(symbol, ")"); end > 0 {
+			symbol = strings.TrimPrefix(symbol[1:end], "*") + symbol[end+1:]
+		}
+	}
+	return symbol
+}
+
+// appliesTo returns true if the API is forbidden in the package.
+func (api forbiddenAPI) appliesTo(pkgPath string) bool {
+	pkgPath = strings.TrimSuffix(pkgPath, "_test")
+	for _, pattern := range api.except {
+		if matchesPackage(pattern, pkgPath) {
+			return false
+		}
+	}
+	if len(api.only) == 0 {
+		return true
+	}
+	for _, pattern := range api.only {
+		if matchesPackage(pattern, pkgPath) {
+			return true
+		}
+	}
+	return false
+}
+
+func (api forbiddenAPI) describe(kind, name string) string {
+	msg := fmt.Sprintf("use of forbidden %s %s", kind, name)
+	if api.message != "" {
+		msg += ": " + api.message
+	}
+	if api.replacement != "" {
+		msg += fmt.Sprintf(" (use %s instead)", api.replacement)
+	}
+	return msg
+}
+
+// ApplyToModule applies the rule to the packages of the module. It is a
+// module rule to find the replacements of forbidden functions in any
+// package of the module.
+func (r *ForbiddenAPIRule) ApplyToModule(module *lint.Module, arguments lint.Arguments, failures chan lint.Failure) {
+	apis, _ := r.parseOptions(arguments) // checked by CheckArguments
+	if len(apis) == 0 {
+		return
+	}
+	packages := knownPackages(module)
+	reported := map[string]bool{}
+	report := func(f lint.Failure) {
+		key := f.Position.Start.String() + f.Failure
+		if !reported[key] {
+			reported[key] = true // file shared by several package variants
+			failures <- f
+		}
+	}
+
+	for _, pkg := range module.Packages {
+		if pkg.TypesPkg == nil || pkg.TypesInfo == nil {
+			continue
+		}
+		applying := []forbiddenAPI{}
+		for _, api := range apis {
+			if api.appliesTo(pkg.TypesPkg.Path()) {
+				applying = append(applying, api)
+			}
+		}
+		if len(applying) == 0 {
+			continue
+		}
+		for _, file := range pkg.Files() {
+			r.checkImports(pkg, file, applying, report)
+			r.checkUses(pkg, file, applying, packages, report)
+		}
+	}
+}
+
+// checkImports reports the imports of forbidden packages.
+func (r *ForbiddenAPIRule) checkImports(pkg *lint.Package, file *lint.File, apis []forbiddenAPI, report func(lint.Failure)) {
+	for _, spec := range file.AST.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		for _, api := range apis {
+			if api.pkg == "" || !matchesPackage(api.pkg, path) {
+				continue
+			}
+			report(lint.Failure{
+				RuleName:   r.Name(),
+				Category:   "imports",
+				Confidence: 1,
+				Failure:    api.describe("package", path),
+				Node:       spec,
+				Position:   lint.FailurePosition{Start: pkg.Fset().Position(spec.Path.Pos()), End: pkg.Fset().Position(spec.End())},
+			})
+		}
+	}
+}
+
+// checkUses reports the uses of forbidden symbols.
+func (r *ForbiddenAPIRule) checkUses(pkg *lint.Package, file *lint.File, apis []forbiddenAPI, packages map[string]*types.Package, report func(lint.Failure)) {
+	// selectors maps the identifiers selected from an imported package to
+	// their selector, for the suggested fixes
+	selectors := map[*ast.Ident]*ast.SelectorExpr{}
+	ast.Inspect(file.AST, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok {
+				if _, ok := pkg.TypesInfo.Uses[id].(*types.PkgName); ok {
+					selectors[sel.Sel] = sel
+				}
+			}
+		}
+		return true
+	})
+
+	failures := []lint.Failure{}
+	fixed := map[*ast.SelectorExpr]*types.Func{}
+	ast.Inspect(file.AST, func(n ast.Node) bool {
+		id, ok := n.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		obj := pkg.TypesInfo.Uses[id]
+		if obj == nil || obj.Pkg() == nil {
+			return true
+		}
+		name, kind := forbiddenName(obj)
+		if name == "" {
+			return true
+		}
+		for _, api := range apis {
+			if api.symbol != name {
+				continue
+			}
+			f := lint.Failure{
+				RuleName:   r.Name(),
+				Category:   "bad practice",
+				Confidence: 1,
+				Failure:    api.describe(kind, name),
+				Node:       id,
+				Position:   lint.FailurePosition{Start: pkg.Fset().Position(id.Pos()), End: pkg.Fset().Position(id.End())},
+			}
+			if sel, ok := selectors[id]; ok && kind == "function" {
+				if replacement := dropInReplacement(obj.(*types.Func), api.replacement, packages); replacement != nil {
+					fixed[sel] = replacement
+					f.SuggestedFix = &lint.SuggestedFix{Message: "replace " + name + " with " + api.replacement}
+				}
+			}
+			failures = append(failures, f)
+		}
+		return true
+	})
+
+	if len(fixed) > 0 {
+		r.addFixEdits(pkg, file, failures, fixed)
+	}
+	for _, f := range failures {
+		report(f)
+	}
+}
+
+// forbiddenName returns the name of the object as given in the options, and
+// its kind, or "" for objects that can not be forbidden.
+func forbiddenName(obj types.Object) (string, string) {
+	obj = lint.Origin(obj)
+	switch o := obj.(type) {
+	case *types.Func:
+		if o.Type().(*types.Signature).Recv() == nil {
+			return qualifiedName(o), "function"
+		}
+		if memberName(o) != o.Name() {
+			return qualifiedName(o), "method"
+		}
+	case *types.Var:
+		if !o.IsField() {
+			if o.Parent() == o.Pkg().Scope() {
+				return qualifiedName(o), "variable"
+			}
+			return "", ""
+		}
+		if owner, ok := fieldOwner(o, o.Pkg()).(*types.Named); ok {
+			return qualifiedName(owner.Obj()) + "." + o.Name(), "field"
+		}
+	case *types.Const:
+		if o.Parent() == o.Pkg().Scope() {
+			return qualifiedName(o), "constant"
+		}
+	case *types.TypeName:
+		if o.Parent() == o.Pkg().Scope() {
+			return qualifiedName(o), "type"
+		}
+	}
+	return "", ""
+}
+
+// knownPackages returns the packages of the module and the packages they
+// import, by path.
+func knownPackages(module *lint.Module) map[string]*types.Package {
+	result := map[string]*types.Package{}
+	var add func(p *types.Package)
+	add = func(p *types.Package) {
+		if _, ok := result[p.Path()]; ok {
+			return
+		}
+		result[p.Path()] = p
+		for _, imp := range p.Imports() {
+			add(imp)
+		}
+	}
+	for _, pkg := range module.Packages {
+		if pkg.TypesPkg != nil {
+			add(pkg.TypesPkg)
+		}
+	}
+	return result
+}
+
+// dropInReplacement returns the replacement function if it is a function
+// with the same signature as fn.
+func dropInReplacement(fn *types.Func, replacement string, packages map[string]*types.Package) *types.Func {
+	slash := strings.LastIndex(replacement, "/")
+	dot := strings.LastIndex(replacement, ".")
+	if dot <= slash {
+		return nil
+	}
+	pkg, ok := packages[replacement[:dot]]
+	if !ok {
+		return nil
+	}
+	result, ok := pkg.Scope().Lookup(replacement[dot+1:]).(*types.Func)
+	if !ok || !types.Identical(result.Type(), fn.Type()) {
+		return nil
+	}
+	return result
+}
+
+// addFixEdits adds to the fixes of the failures of the file the edits
+// replacing the forbidden functions. Every fix is self-contained: it also
+// adds the import of the replacement, and removes the import of the forbidden
+// function if the fix replaces its last use in the file.
+func (r *ForbiddenAPIRule) addFixEdits(pkg *lint.Package, file *lint.File, failures []lint.Failure, fixed map[*ast.SelectorExpr]*types.Func) {
+	fset := pkg.Fset()
+	// names are the local names of the imported packages, by path
+	names := map[string]string{}
+	taken := map[string]bool{}
+	for _, spec := range file.AST.Imports {
+		if pkgName := pkg.TypesInfo.PkgNameOf(spec); pkgName != nil {
+			names[pkgName.Imported().Path()] = pkgName.Name()
+			taken[pkgName.Name()] = true
+		}
+	}
+
+	// added are the edits adding the imports of the replacements, by path
+	added := map[string]lint.Edit{}
+	// uses counts the uses of the imports in the file
+	uses := map[*types.PkgName]int{}
+	for id, obj := range pkg.TypesInfo.Uses {
+		if pkgName, ok := obj.(*types.PkgName); ok && fset.File(id.Pos()) == fset.File(file.AST.Pos()) {
+			uses[pkgName]++
+		}
+	}
+
+	edits := map[token.Pos][]lint.Edit{}
+	for sel, replacement := range fixed {
+		path := replacement.Pkg().Path()
+		name, ok := names[path]
+		if !ok {
+			name = replacement.Pkg().Name()
+			if taken[name] {
+				continue // name conflict, no fix
+			}
+			names[path], taken[name] = name, true
+			added[path] = r.importEdit(file, fset, path)
+		}
+		fixEdits := []lint.Edit{{
+			Start:   fset.Position(sel.Pos()),
+			End:     fset.Position(sel.End()),
+			NewText: name + "." + replacement.Name(),
+		}}
+		if edit, ok := added[path]; ok {
+			fixEdits = append(fixEdits, edit)
+		}
+		if pkgName := pkg.TypesInfo.Uses[sel.X.(*ast.Ident)].(*types.PkgName); uses[pkgName] == 1 {
+			for _, spec := range file.AST.Imports {
+				if pkg.TypesInfo.PkgNameOf(spec) == pkgName {
+					fixEdits = append(fixEdits, removeLineEdit(fset, spec))
+				}
+			}
+		}
+		edits[sel.Sel.Pos()] = fixEdits
+	}
+
+	for i := range failures {
+		f := &failures[i]
+		if f.SuggestedFix == nil {
+			continue
+		}
+		fixEdits, ok := edits[f.Node.Pos()]
+		if !ok {
+			f.SuggestedFix = nil
+			continue
+		}
+		f.SuggestedFix.Edits = fixEdits
+	}
+}
+
+// removeLineEdit returns the edit removing the line of the import spec.
+func removeLineEdit(fset *token.FileSet, spec *ast.ImportSpec) lint.Edit {
+	start, end := fset.Position(spec.Pos()), fset.Position(spec.End())
+	start.Offset -= start.Column - 1
+	start.Column = 1
+	end.Offset++ // newline
+	end.Line++
+	end.Column = 1
+	return lint.Edit{Start: start, End: end}
+}
+
+// importEdit returns the edit adding the import of path to the file, at the
+// end of its first import declaration, or before it if it is not grouped.
+func (r *ForbiddenAPIRule) importEdit(file *lint.File, fset *token.FileSet, path string) lint.Edit {
+	for _, decl := range file.AST.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+		if gen.Rparen.IsValid() {
+			position := fset.Position(gen.Rparen)
+			return lint.Edit{Start: position, End: position, NewText: "\t" + strconv.Quote(path) + "\n"}
+		}
+		position := fset.Position(gen.Pos())
+		return lint.Edit{Start: position, End: position, NewText: "import " + strconv.Quote(path) + "\n"}
+	}
+	position := fset.Position(file.AST.Name.End())
+	return lint.Edit{Start: position, End: position, NewText: "\n\nimport " + strconv.Quote(path)}
+}
+
+// ApplyToPackage applies the rule to given package.
+func (r *ForbiddenAPIRule) ApplyToPackage(pkg *lint.Package, arguments lint.Arguments, failures chan lint.Failure) {
+}
+
+// ApplyToFile applies the rule to given file.
+func (r *ForbiddenAPIRule) ApplyToFile(file *lint.File, arguments lint.Arguments) []lint.Failure {
+	return nil
+}
+
+// Name returns the rule name.
+func (r *ForbiddenAPIRule) Name() string {
+	return "forbidden-api"
+}
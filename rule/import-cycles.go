@@ -0,0 +1,343 @@
+package rule
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"sort"
+	"strings"
+
+	"github.com/chavacava/gusano/depgraph"
+	"github.com/chavacava/gusano/lint"
+)
+
+// ImportCyclesRule lints the structures of the import graph of the module
+// that are one step away from an import cycle, which Go rejects:
+//   - external test packages (p_test) importing a package that imports p:
+//     the tests could not be moved into p,
+//   - directories whose packages import each other, a cycle at the level of
+//     the directories hidden by the split in packages (e.g. a package whose
+//     internal packages import a package that imports it). Directories are
+//     made of the first elements of the package paths below the module path.
+//
+// Failures name the symbols making the back-reference, to know what to move
+// to break the cycle.
+type ImportCyclesRule struct{}
+
+// importCyclesOptions are the options of the rule, given as a table argument:
+//
+//	[rule.import-cycles]
+//	arguments = [{ tests = false, directories = true, dirDepth = 2 }]
+type importCyclesOptions struct {
+	// tests enables the analysis of the external test packages
+	tests bool
+	// directories enables the analysis of the directories
+	directories bool
+	// dirDepth is the number of path elements below the module path that
+	// identify a directory
+	dirDepth int
+}
+
+func (r *ImportCyclesRule) parseOptions(arguments lint.Arguments) (importCyclesOptions, error) {
+	options := importCyclesOptions{tests: true, directories: true, dirDepth: 1}
+	err := parseTables(arguments, r.Name(), func(option string, value interface{}) error {
+		var err error
+		switch option {
+		case "tests":
+			options.tests, err = toBool(value)
+		case "directories":
+			options.directories, err = toBool(value)
+		case "dirDepth":
+			options.dirDepth, err = toInt(value, 1)
+		default:
+			return errUnknownOption
+		}
+		return err
+	})
+	return options, err
+}
+
+// CheckArguments checks the arguments of the rule.
+func (r *ImportCyclesRule) CheckArguments(arguments lint.Arguments) error {
+	_, err := r.parseOptions(arguments)
+	return err
+}
+
+// importEdge is an import between two packages of the module.
+type importEdge struct {
+	from, to string
+	// spec is the first import spec of to in from
+	spec *ast.ImportSpec
+	pkg  *lint.Package
+	// symbols are the symbols of to used by from
+	symbols map[string]bool
+}
+
+// importGraph is the import graph of the packages of the module, variants of
+// a package merged.
+type importGraph struct {
+	edges map[string]map[string]*importEdge
+	// tests are the external test packages
+	tests map[string]bool
+	// modules maps the packages to the path of their module
+	modules map[string]string
+}
+
+func newImportGraph(module *lint.Module) *importGraph {
+	g := &importGraph{edges: map[string]map[string]*importEdge{}, tests: map[string]bool{}, modules: map[string]string{}}
+	inModule := map[string]bool{}
+	for _, pkg := range module.Packages {
+		if pkg.TypesPkg != nil {
+			inModule[pkg.TypesPkg.Path()] = true
+		}
+	}
+
+	for _, pkg := range module.Packages {
+		if pkg.TypesPkg == nil || pkg.TypesInfo == nil {
+			continue
+		}
+		from := pkg.TypesPkg.Path()
+		g.modules[from] = pkg.ModulePath
+		if strings.HasSuffix(from, "_test") {
+			g.tests[from] = true
+		}
+		if g.edges[from] == nil {
+			g.edges[from] = map[string]*importEdge{}
+		}
+		for _, file := range pkg.Files() {
+			for _, spec := range file.AST.Imports {
+				pkgName := pkg.TypesInfo.PkgNameOf(spec)
+				if pkgName == nil || !inModule[pkgName.Imported().Path()] {
+					continue
+				}
+				to := pkgName.Imported().Path()
+				if _, ok := g.edges[from][to]; !ok {
+					g.edges[from][to] = &importEdge{from: from, to: to, spec: spec, pkg: pkg, symbols: map[string]bool{}}
+				}
+			}
+		}
+		for _, obj := range pkg.TypesInfo.Uses {
+			if obj.Pkg() == nil {
+				continue
+			}
+			e, ok := g.edges[from][obj.Pkg().Path()]
+			if !ok {
+				continue
+			}
+			if _, ok := obj.(*types.PkgName); ok {
+				continue
+			}
+			if v, ok := obj.(*types.Var); ok && v.IsField() {
+				continue // fields are reached through their type
+			}
+			obj = lint.Origin(obj)
+			e.symbols[obj.Pkg().Name()+"."+memberName(obj)] = true
+		}
+	}
+	return g
+}
+
+// path returns the shortest path of imports from one package to another,
+// without going through external test packages, or nil.
+func (g *importGraph) path(from, to string) []string {
+	parent := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == to {
+			result := []string{}
+			for p := to; p != ""; p = parent[p] {
+				result = append([]string{p}, result...)
+			}
+			return result
+		}
+		for _, next := range sortedKeys(g.edges[current]) {
+			if _, seen := parent[next]; !seen && !g.tests[next] {
+				parent[next] = current
+				queue = append(queue, next)
+			}
+		}
+	}
+	return nil
+}
+
+// ApplyToModule applies the rule to the import graph of the module.
+func (r *ImportCyclesRule) ApplyToModule(module *lint.Module, arguments lint.Arguments, failures chan lint.Failure) {
+	options, _ := r.parseOptions(arguments) // checked by CheckArguments
+	g := newImportGraph(module)
+	if options.tests {
+		r.checkTests(g, failures)
+	}
+	if options.directories {
+		r.checkDirectories(g, options.dirDepth, failures)
+	}
+}
+
+// checkTests reports the imports of external test packages p_test of
+// packages that import p.
+func (r *ImportCyclesRule) checkTests(g *importGraph, failures chan lint.Failure) {
+	for _, test := range sortedKeys(g.edges) {
+		if !g.tests[test] {
+			continue
+		}
+		tested := strings.TrimSuffix(test, "_test")
+		for _, to := range sortedKeys(g.edges[test]) {
+			if to == tested {
+				continue
+			}
+			p := g.path(to, tested)
+			if p == nil {
+				continue
+			}
+			back := g.edges[p[len(p)-2]][tested]
+			failures <- r.failure(g.edges[test][to], fmt.Sprintf(
+				"the tests of package %s could not be moved into it: %s imports it back through %s, for %s",
+				tested, to, strings.Join(p, " -> "), symbolList(back.symbols)))
+		}
+	}
+}
+
+// checkDirectories reports the groups of directories whose packages import
+// each other, at the import of the group carrying the fewest symbols.
+func (r *ImportCyclesRule) checkDirectories(g *importGraph, dirDepth int, failures chan lint.Failure) {
+	dirEdges := map[string]map[string][]*importEdge{}
+	for from, edges := range g.edges {
+		if g.tests[from] {
+			continue
+		}
+		for to, e := range edges {
+			fromDir := depgraph.DirectoryOf(from, g.modules[from], dirDepth)
+			toDir := depgraph.DirectoryOf(to, g.modules[to], dirDepth)
+			if fromDir == toDir {
+				continue
+			}
+			if dirEdges[fromDir] == nil {
+				dirEdges[fromDir] = map[string][]*importEdge{}
+			}
+			dirEdges[fromDir][toDir] = append(dirEdges[fromDir][toDir], e)
+		}
+	}
+
+	for _, component := range stronglyConnected(dirEdges) {
+		in := map[string]bool{}
+		for _, dir := range component {
+			in[dir] = true
+		}
+		var weakest *importEdge
+		var imports []string
+		for _, from := range component {
+			for _, to := range sortedKeys(dirEdges[from]) {
+				if !in[to] {
+					continue
+				}
+				for _, e := range dirEdges[from][to] {
+					imports = append(imports, e.from+" -> "+e.to)
+					if weakest == nil || len(e.symbols) < len(weakest.symbols) ||
+						len(e.symbols) == len(weakest.symbols) && e.from+e.to < weakest.from+weakest.to {
+						weakest = e
+					}
+				}
+			}
+		}
+		sort.Strings(imports)
+		failures <- r.failure(weakest, fmt.Sprintf(
+			"directories %s import each other (%s): moving %s out of %s would remove the import of %s",
+			strings.Join(component, ", "), strings.Join(imports, ", "), symbolList(weakest.symbols), weakest.to, weakest.from))
+	}
+}
+
+// stronglyConnected returns the strongly connected components of the graph
+// that have more than one node, with their nodes sorted (Tarjan's algorithm).
+func stronglyConnected(edges map[string]map[string][]*importEdge) [][]string {
+	index := map[string]int{}
+	lowlink := map[string]int{}
+	onStack := map[string]bool{}
+	stack := []string{}
+	result := [][]string{}
+
+	var visit func(n string)
+	visit = func(n string) {
+		index[n] = len(index)
+		lowlink[n] = index[n]
+		stack = append(stack, n)
+		onStack[n] = true
+		for _, m := range sortedKeys(edges[n]) {
+			if _, ok := index[m]; !ok {
+				visit(m)
+				if lowlink[m] < lowlink[n] {
+					lowlink[n] = lowlink[m]
+				}
+			} else if onStack[m] && index[m] < lowlink[n] {
+				lowlink[n] = index[m]
+			}
+		}
+		if lowlink[n] != index[n] {
+			return
+		}
+		component := []string{}
+		for {
+			m := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[m] = false
+			component = append(component, m)
+			if m == n {
+				break
+			}
+		}
+		if len(component) > 1 {
+			sort.Strings(component)
+			result = append(result, component)
+		}
+	}
+
+	for _, n := range sortedKeys(edges) {
+		if _, ok := index[n]; !ok {
+			visit(n)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i][0] < result[j][0] })
+	return result
+}
+
+func (r *ImportCyclesRule) failure(e *importEdge, msg string) lint.Failure {
+	return lint.Failure{
+		RuleName:   r.Name(),
+		Category:   "architecture",
+		Confidence: 1,
+		Failure:    msg,
+		Node:       e.spec,
+		Position:   lint.FailurePosition{Start: e.pkg.Fset().Position(e.spec.Path.Pos()), End: e.pkg.Fset().Position(e.spec.End())},
+	}
+}
+
+// symbolList returns the sorted list of the symbols for failure messages.
+func symbolList(symbols map[string]bool) string {
+	if len(symbols) == 0 {
+		return "no symbol (blank import)"
+	}
+	return strings.Join(sortedKeys(symbols), ", ")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	result := make([]string, 0, len(m))
+	for k := range m {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// ApplyToPackage applies the rule to given package.
+func (r *ImportCyclesRule) ApplyToPackage(pkg *lint.Package, arguments lint.Arguments, failures chan lint.Failure) {
+}
+
+// ApplyToFile applies the rule to given file.
+func (r *ImportCyclesRule) ApplyToFile(file *lint.File, arguments lint.Arguments) []lint.Failure {
+	return nil
+}
+
+// Name returns the rule name.
+func (r *ImportCyclesRule) Name() string {
+	return "import-cycles"
+}
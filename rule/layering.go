@@ -0,0 +1,193 @@
+package rule
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/chavacava/gusano/lint"
+)
+
+// LayeringRule lints the imports that cross the boundaries of the
+// architecture layers of the module.
+// Packages are mapped to named layers by patterns, and each layer declares
+// the layers it may import. Imports within a layer are always allowed, as
+// well as imports of packages that belong to no layer (e.g. the standard
+// library).
+type LayeringRule struct{}
+
+// layeringOptions are the options of the rule, given as a table argument:
+//
+//	[rule.layering]
+//	arguments = [{ layers = { domain = ["example.com/mod/domain/..."], app = ["example.com/mod/app/..."] }, allow = { app = ["domain"] } }]
+type layeringOptions struct {
+	// patterns maps the package patterns to their layer
+	patterns map[string]string
+	// allowed maps the layers to the layers they may import
+	allowed map[string]map[string]bool
+}
+
+func (r *LayeringRule) parseOptions(arguments lint.Arguments) (layeringOptions, error) {
+	options := layeringOptions{patterns: map[string]string{}, allowed: map[string]map[string]bool{}}
+	allow := map[string][]string{}
+	err := parseTables(arguments, r.Name(), func(option string, value interface{}) error {
+		switch option {
+		case "layers":
+			layers, ok := value.(map[string]interface{})
+			if !ok {
+				return errors.New("expected a table of package patterns by layer")
+			}
+			for layer, value := range layers {
+				patterns, err := toStrings(value)
+				if err != nil {
+					return fmt.Errorf("layer %s: %v", layer, err)
+				}
+				options.allowed[layer] = map[string]bool{}
+				for _, pattern := range patterns {
+					if other, ok := options.patterns[pattern]; ok && other != layer {
+						return fmt.Errorf("pattern %q belongs to layers %s and %s", pattern, other, layer)
+					}
+					options.patterns[pattern] = layer
+				}
+			}
+		case "allow":
+			layers, ok := value.(map[string]interface{})
+			if !ok {
+				return errors.New("expected a table of layers by layer")
+			}
+			for layer, value := range layers {
+				imported, err := toStrings(value)
+				if err != nil {
+					return fmt.Errorf("layer %s: %v", layer, err)
+				}
+				allow[layer] = imported
+			}
+		default:
+			return errUnknownOption
+		}
+		return nil
+	})
+	if err != nil {
+		return options, err
+	}
+
+	for layer, imported := range allow {
+		if _, ok := options.allowed[layer]; !ok {
+			return options, fmt.Errorf("invalid option allow of %s rule: unknown layer %s", r.Name(), layer)
+		}
+		for _, other := range imported {
+			if _, ok := options.allowed[other]; !ok {
+				return options, fmt.Errorf("invalid option allow of %s rule: unknown layer %s", r.Name(), other)
+			}
+			options.allowed[layer][other] = true
+		}
+	}
+	return options, nil
+}
+
+// CheckArguments checks the arguments of the rule.
+func (r *LayeringRule) CheckArguments(arguments lint.Arguments) error {
+	_, err := r.parseOptions(arguments)
+	return err
+}
+
+// layerOf returns the layer of the package, given by its most specific
+// matching pattern, or "" if the package belongs to no layer.
+func (o layeringOptions) layerOf(path string) string {
+	layer, best := "", -1
+	for pattern, l := range o.patterns {
+		if s := patternSpecificity(pattern); s > best && matchesPackage(pattern, path) {
+			layer, best = l, s
+		}
+	}
+	return layer
+}
+
+// patternSpecificity ranks the package patterns matching a same package:
+// the longer the path of the pattern, the more specific, and a path is more
+// specific than the same path followed by "/...".
+// Two distinct patterns matching a package can not have the same rank.
+func patternSpecificity(pattern string) int {
+	if pattern == "..." {
+		return 0
+	}
+	if prefix := strings.TrimSuffix(pattern, "/..."); prefix != pattern {
+		return 2 * len(prefix)
+	}
+	return 2*len(pattern) + 1
+}
+
+// ApplyToModule applies the rule to the imports of the packages of the module.
+func (r *LayeringRule) ApplyToModule(module *lint.Module, arguments lint.Arguments, failures chan lint.Failure) {
+	options, _ := r.parseOptions(arguments) // checked by CheckArguments
+	if len(options.patterns) == 0 {
+		return
+	}
+
+	reported := map[string]bool{}
+	for _, pkg := range module.Packages {
+		if pkg.TypesPkg == nil || pkg.TypesInfo == nil {
+			continue
+		}
+		from := pkg.TypesPkg.Path()
+		fromLayer := options.layerOf(strings.TrimSuffix(from, "_test"))
+		if fromLayer == "" {
+			continue
+		}
+		for _, file := range pkg.Files() {
+			for _, spec := range file.AST.Imports {
+				pkgName := pkg.TypesInfo.PkgNameOf(spec)
+				if pkgName == nil {
+					continue
+				}
+				to := pkgName.Imported().Path()
+				toLayer := options.layerOf(to)
+				if toLayer == "" || toLayer == fromLayer || options.allowed[fromLayer][toLayer] {
+					continue
+				}
+
+				position := pkg.Fset().Position(spec.Path.Pos())
+				if reported[position.String()] {
+					continue // file shared by several package variants
+				}
+				reported[position.String()] = true
+				failures <- lint.Failure{
+					RuleName:   r.Name(),
+					Category:   "architecture",
+					Confidence: 1,
+					Failure:    fmt.Sprintf("package %s of layer %s must not import %s of layer %s%s", from, fromLayer, to, toLayer, allowedHint(options.allowed[fromLayer])),
+					Node:       spec,
+					Position:   lint.FailurePosition{Start: position, End: pkg.Fset().Position(spec.End())},
+				}
+			}
+		}
+	}
+}
+
+// allowedHint returns the list of the layers that can be imported, for the failure message.
+func allowedHint(allowed map[string]bool) string {
+	if len(allowed) == 0 {
+		return " (it can not import other layers)"
+	}
+	layers := make([]string, 0, len(allowed))
+	for layer := range allowed {
+		layers = append(layers, layer)
+	}
+	sort.Strings(layers)
+	return " (it can only import " + strings.Join(layers, ", ") + ")"
+}
+
+// ApplyToPackage applies the rule to given package.
+func (r *LayeringRule) ApplyToPackage(pkg *lint.Package, arguments lint.Arguments, failures chan lint.Failure) {
+}
+
+// ApplyToFile applies the rule to given file.
+func (r *LayeringRule) ApplyToFile(file *lint.File, arguments lint.Arguments) []lint.Failure {
+	return nil
+}
+
+// Name returns the rule name.
+func (r *LayeringRule) Name() string {
+	return "layering"
+}
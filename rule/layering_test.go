@@ -0,0 +1,33 @@
+package rule
+
+import "testing"
+
+func TestLayerOf(t *testing.T) {
+	options := layeringOptions{patterns: map[string]string{
+		"...":           "all",
+		"m/a/...":       "a",
+		"m/a/b":         "b",
+		"m/a/b/...":     "b-tree",
+		"m/a/b/c/d/...": "d",
+		"m/a/b/c/d":     "d-exact",
+	}}
+	tests := []struct {
+		path, want string
+	}{
+		{"fmt", "all"},
+		{"m/a", "a"},
+		{"m/a/x", "a"},
+		{"m/a/b", "b"},
+		{"m/a/b/c", "b-tree"},
+		{"m/a/b/c/d", "d-exact"},
+		{"m/a/b/c/d/e", "d"},
+	}
+	for _, test := range tests {
+		// iterate several times, as the patterns are a map
+		for i := 0; i < 20; i++ {
+			if got := options.layerOf(test.path); got != test.want {
+				t.Fatalf("layerOf(%q) = %q, want %q", test.path, got, test.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package rule
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+
+	"github.com/chavacava/gusano/lint"
+	"github.com/chavacava/gusano/metrics"
+)
+
+// PackageMetricsRule lints the packages whose coupling and cohesion metrics
+// exceed the configured thresholds: afferent and efferent coupling,
+// instability, distance from the main sequence and lack of cohesion of the
+// methods (LCOM) of their types.
+// Thresholds that are not configured are not checked.
+type PackageMetricsRule struct{}
+
+// packageMetricsOptions are the options of the rule, given as a table argument:
+//
+//	[rule.package-metrics]
+//	arguments = [{ maxAfferent = 20, maxEfferent = 10, maxInstability = 0.9, maxDistance = 0.7, maxLCOM = 2 }]
+type packageMetricsOptions struct {
+	maxAfferent    int
+	maxEfferent    int
+	maxLCOM        int
+	maxInstability float64
+	maxDistance    float64
+}
+
+func (r *PackageMetricsRule) parseOptions(arguments lint.Arguments) (packageMetricsOptions, error) {
+	options := packageMetricsOptions{maxAfferent: -1, maxEfferent: -1, maxLCOM: -1, maxInstability: -1, maxDistance: -1}
+	err := parseTables(arguments, r.Name(), func(option string, value interface{}) error {
+		var err error
+		switch option {
+		case "maxAfferent":
+			options.maxAfferent, err = toInt(value, 0)
+		case "maxEfferent":
+			options.maxEfferent, err = toInt(value, 0)
+		case "maxLCOM":
+			options.maxLCOM, err = toInt(value, 0)
+		case "maxInstability":
+			options.maxInstability, err = toRatio(value)
+		case "maxDistance":
+			options.maxDistance, err = toRatio(value)
+		default:
+			return errUnknownOption
+		}
+		return err
+	})
+	return options, err
+}
+
+// toRatio converts a rule option value to a number between 0 and 1.
+func toRatio(value interface{}) (float64, error) {
+	var f float64
+	switch v := value.(type) {
+	case float64:
+		f = v
+	case int64:
+		f = float64(v)
+	default:
+		f = -1
+	}
+	if f < 0 || f > 1 {
+		return 0, errors.New("expected a number between 0 and 1")
+	}
+	return f, nil
+}
+
+// CheckArguments checks the arguments of the rule.
+func (r *PackageMetricsRule) CheckArguments(arguments lint.Arguments) error {
+	_, err := r.parseOptions(arguments)
+	return err
+}
+
+// ApplyToModule applies the rule to the packages of the module.
+func (r *PackageMetricsRule) ApplyToModule(module *lint.Module, arguments lint.Arguments, failures chan lint.Failure) {
+	options, _ := r.parseOptions(arguments) // checked by CheckArguments
+
+	for _, m := range metrics.Compute(module.Packages) {
+		file := reportedFile(m.Pkg)
+		if file == nil {
+			continue
+		}
+		report := func(format string, args ...interface{}) {
+			failures <- lint.Failure{
+				RuleName:   r.Name(),
+				Category:   "metrics",
+				Confidence: 1,
+				Failure:    fmt.Sprintf("package %s ", m.Path) + fmt.Sprintf(format, args...),
+				Node:       file.Name,
+				Position:   lint.FailurePosition{Start: m.Pkg.Fset().Position(file.Name.Pos()), End: m.Pkg.Fset().Position(file.Name.End())},
+			}
+		}
+
+		if options.maxAfferent >= 0 && m.Afferent > options.maxAfferent {
+			report("is imported by %d packages (max %d)", m.Afferent, options.maxAfferent)
+		}
+		if options.maxEfferent >= 0 && m.Efferent > options.maxEfferent {
+			report("imports %d packages (max %d)", m.Efferent, options.maxEfferent)
+		}
+		if options.maxInstability >= 0 && m.Instability > options.maxInstability {
+			report("has an instability of %.2f (max %.2f)", m.Instability, options.maxInstability)
+		}
+		if options.maxDistance >= 0 && m.Distance > options.maxDistance {
+			report("is at %.2f from the main sequence (max %.2f, abstractness %.2f, instability %.2f)", m.Distance, options.maxDistance, m.Abstractness, m.Instability)
+		}
+		if options.maxLCOM < 0 {
+			continue
+		}
+		for _, t := range m.Types {
+			if t.LCOM <= options.maxLCOM {
+				continue
+			}
+			position := m.Pkg.Fset().Position(t.Pos)
+			failures <- lint.Failure{
+				RuleName:   r.Name(),
+				Category:   "metrics",
+				Confidence: 1,
+				Failure:    fmt.Sprintf("the %d methods of type %s form %d unrelated groups (max LCOM %d), consider splitting it", t.Methods, t.Name, t.LCOM, options.maxLCOM),
+				Position:   lint.FailurePosition{Start: position, End: position},
+			}
+		}
+	}
+}
+
+// reportedFile returns the file whose package clause holds the failures of
+// the package: the first non-test file, or the first file.
+func reportedFile(pkg *lint.Package) *ast.File {
+	files := pkg.Files()
+	for _, f := range files {
+		if !f.IsTest() {
+			return f.AST
+		}
+	}
+	if len(files) > 0 {
+		return files[0].AST
+	}
+	return nil
+}
+
+// ApplyToPackage applies the rule to given package.
+func (r *PackageMetricsRule) ApplyToPackage(pkg *lint.Package, arguments lint.Arguments, failures chan lint.Failure) {
+}
+
+// ApplyToFile applies the rule to given file.
+func (r *PackageMetricsRule) ApplyToFile(file *lint.File, arguments lint.Arguments) []lint.Failure {
+	return nil
+}
+
+// Name returns the rule name.
+func (r *PackageMetricsRule) Name() string {
+	return "package-metrics"
+}
@@ -0,0 +1,779 @@
+package rule
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/chavacava/gusano/lint"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// refGraph is the graph of references between the declarations of a package.
+// A reference goes from the declaration enclosing an identifier to the
+// declaration the identifier refers to.
+type refGraph struct {
+	pkg *lint.Package
+	// nodes maps the declarations subject to the reachability analysis to their defining identifier.
+	nodes map[types.Object]*ast.Ident
+	// refs maps a declaration to the declarations it refers to.
+	// References made from root contexts (initializers with side effects, blank
+	// vars...) have a nil source, or testContext when made from test files.
+	refs map[types.Object][]types.Object
+	// referrers maps a declaration to the declarations referring to it.
+	referrers map[types.Object][]types.Object
+	// roots are the declarations that are reachable by definition.
+	roots map[types.Object]bool
+	// conversions maps the named types of the package to the declarations
+	// where their values are converted to interfaces.
+	conversions map[*types.TypeName][]types.Object
+	// writes maps the fields to the declarations writing them.
+	// Writes are not references: a field only written is not reachable.
+	writes map[types.Object][]types.Object
+	// writeIdents are the identifiers of fields being written
+	writeIdents map[*ast.Ident]bool
+	// reflected are the exported fields that can be read through reflection
+	// because values containing them are converted to interfaces
+	reflected  map[types.Object]bool
+	options    unusedSymbolOptions
+	methodSets typeutil.MethodSetCache
+	interfaces []*types.TypeName
+	// testFiles are the names of the test files of the package
+	testFiles map[string]bool
+	// testContext is the source of the references made from root contexts of test files
+	testContext types.Object
+}
+
+var linknameRE = regexp.MustCompile(`^//go:linkname\s+(\S+)`)
+
+// newRefGraph builds the reference graph of the given package.
+func newRefGraph(pkg *lint.Package, options unusedSymbolOptions) *refGraph {
+	g := &refGraph{
+		pkg:         pkg,
+		nodes:       map[types.Object]*ast.Ident{},
+		refs:        map[types.Object][]types.Object{},
+		referrers:   map[types.Object][]types.Object{},
+		roots:       map[types.Object]bool{},
+		conversions: map[*types.TypeName][]types.Object{},
+		writes:      map[types.Object][]types.Object{},
+		writeIdents: map[*ast.Ident]bool{},
+		reflected:   map[types.Object]bool{},
+		options:     options,
+		testFiles:   map[string]bool{},
+		testContext: types.NewLabel(token.NoPos, pkg.TypesPkg, "tests"),
+	}
+	for _, file := range pkg.Files() {
+		if file.IsTest() {
+			g.testFiles[file.Name] = true
+		}
+	}
+	g.roots[g.testContext] = true
+
+	info := pkg.TypesInfo
+	for id, obj := range info.Defs {
+		if obj != nil && g.isNode(obj) {
+			g.nodes[obj] = id
+			if options.isEntryPoint(obj) || options.isKept(obj) || obj.Exported() && !isMethod(obj) && !isField(obj) {
+				g.roots[obj] = true
+			}
+		}
+	}
+	g.addAPIRoots()
+	for obj := range directiveRoots(pkg) {
+		if g.isNode(obj) {
+			g.roots[obj] = true
+		}
+	}
+
+	for _, file := range pkg.Files() {
+		var ctx types.Object
+		if file.IsTest() {
+			ctx = g.testContext
+		}
+		g.addTaggedFieldRoots(file.AST)
+
+		for _, decl := range file.AST.Decls {
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				src := info.Defs[d.Name]
+				isRoot := d.Recv == nil && (d.Name.Name == "init" || (d.Name.Name == "main" && pkg.IsMain()))
+				if isRoot && src != nil {
+					g.roots[src] = true
+				}
+				g.addRefs(src, d)
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					switch s := spec.(type) {
+					case *ast.TypeSpec:
+						g.addRefs(info.Defs[s.Name], s)
+					case *ast.ValueSpec:
+						// initializers of package-level vars that call functions have side effects
+						if d.Tok == token.VAR && hasCall(s.Values) {
+							g.addRefs(ctx, s)
+							continue
+						}
+						for _, name := range s.Names {
+							src := info.Defs[name]
+							if name.Name == "_" {
+								src = ctx
+							}
+							g.addRefs(src, s)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return g
+}
+
+// isNode returns true if the object is a declaration of the package that is
+// not local to a function: package-level declarations, methods and fields.
+func (g *refGraph) isNode(obj types.Object) bool {
+	if obj.Pkg() != g.pkg.TypesPkg {
+		return false
+	}
+	switch obj.(type) {
+	case *types.Label, *types.PkgName:
+		return false
+	}
+	return obj.Parent() == nil || obj.Parent() == g.pkg.TypesPkg.Scope()
+}
+
+// addRefs adds the references, made under the given node, from src.
+func (g *refGraph) addRefs(src types.Object, node ast.Node) {
+	ast.Walk(&refVisitor{g: g, src: src}, node)
+}
+
+func (g *refGraph) addRef(src, obj types.Object) {
+	g.refs[src] = append(g.refs[src], obj)
+	g.referrers[obj] = append(g.referrers[obj], src)
+}
+
+// refVisitor adds the references made under a declaration, including those
+// resulting from the conversion of values to interfaces.
+type refVisitor struct {
+	g   *refGraph
+	src types.Object
+	// results of the enclosing function
+	results *types.Tuple
+}
+
+func (v *refVisitor) Visit(node ast.Node) ast.Visitor {
+	info := v.g.pkg.TypesInfo
+	switch n := node.(type) {
+	case *ast.Ident:
+		v.visitIdent(n)
+	case *ast.FuncDecl:
+		if sig, ok := info.TypeOf(n.Name).(*types.Signature); ok {
+			return &refVisitor{v.g, v.src, sig.Results()}
+		}
+	case *ast.FuncLit:
+		if sig, ok := info.TypeOf(n).(*types.Signature); ok {
+			return &refVisitor{v.g, v.src, sig.Results()}
+		}
+	case *ast.CallExpr:
+		v.visitCall(n)
+	case *ast.IncDecStmt:
+		v.markWrite(n.X)
+	case *ast.AssignStmt:
+		v.visitAssign(n)
+	case *ast.CompositeLit:
+		v.visitCompositeLit(n)
+	case *ast.BinaryExpr, *ast.IndexExpr:
+		v.visitComparison(n)
+	case *ast.ValueSpec, *ast.ReturnStmt, *ast.SendStmt:
+		v.visitConversion(n)
+	}
+	return v
+}
+
+// visitIdent adds the reference, or the write, made by the identifier.
+func (v *refVisitor) visitIdent(id *ast.Ident) {
+	obj := lint.Origin(v.g.pkg.TypesInfo.Uses[id])
+	if obj == nil || obj == v.src || !v.g.isNode(obj) {
+		return
+	}
+	if v.g.writeIdents[id] {
+		v.g.writes[obj] = append(v.g.writes[obj], v.src)
+		return
+	}
+	v.g.addRef(v.src, obj)
+}
+
+// visitAssign marks the fields assigned as written and converts the assigned
+// values to the types of the variables.
+func (v *refVisitor) visitAssign(n *ast.AssignStmt) {
+	if n.Tok != token.DEFINE {
+		for _, lhs := range n.Lhs {
+			v.markWrite(lhs)
+		}
+	}
+	if n.Tok == token.ASSIGN && len(n.Lhs) == len(n.Rhs) {
+		for i, lhs := range n.Lhs {
+			v.convert(n.Rhs[i], v.g.pkg.TypesInfo.TypeOf(lhs))
+		}
+	}
+}
+
+// visitConversion converts the values of typed variable declarations, of
+// returns and of sends to the types they are assigned to.
+func (v *refVisitor) visitConversion(node ast.Node) {
+	info := v.g.pkg.TypesInfo
+	switch n := node.(type) {
+	case *ast.ValueSpec:
+		if n.Type != nil {
+			for _, value := range n.Values {
+				v.convert(value, info.TypeOf(n.Type))
+			}
+		}
+	case *ast.ReturnStmt:
+		if v.results != nil && len(n.Results) == v.results.Len() {
+			for i, r := range n.Results {
+				v.convert(r, v.results.At(i).Type())
+			}
+		}
+	case *ast.SendStmt:
+		if ch, ok := underlying(info.TypeOf(n.Chan)).(*types.Chan); ok {
+			v.convert(n.Value, ch.Elem())
+		}
+	}
+}
+
+// visitComparison reads the fields of the compared values: comparisons read
+// all the fields of structs, and map lookups compare keys.
+func (v *refVisitor) visitComparison(node ast.Node) {
+	info := v.g.pkg.TypesInfo
+	switch n := node.(type) {
+	case *ast.BinaryExpr:
+		if n.Op == token.EQL || n.Op == token.NEQ {
+			v.g.addFieldReads(v.src, info.TypeOf(n.X), false, map[types.Type]bool{})
+		}
+	case *ast.IndexExpr:
+		if m, ok := underlying(info.TypeOf(n.X)).(*types.Map); ok {
+			v.g.addFieldReads(v.src, m.Key(), false, map[types.Type]bool{})
+		}
+	}
+}
+
+// markWrite marks the field selected by expr, if any, as being written.
+func (v *refVisitor) markWrite(expr ast.Expr) {
+	for {
+		paren, ok := expr.(*ast.ParenExpr)
+		if !ok {
+			break
+		}
+		expr = paren.X
+	}
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return
+	}
+	if s, ok := v.g.pkg.TypesInfo.Selections[sel]; ok && s.Kind() == types.FieldVal {
+		v.g.writeIdents[sel.Sel] = true
+	}
+}
+
+func (v *refVisitor) visitCall(call *ast.CallExpr) {
+	info := v.g.pkg.TypesInfo
+	if callee := typeutil.Callee(info, call); callee != nil && v.g.options.fieldReaders[qualifiedName(callee)] {
+		// fields of the arguments are read through reflection or their memory layout
+		for _, arg := range call.Args {
+			v.g.addFieldReads(v.src, info.TypeOf(arg), false, map[types.Type]bool{})
+		}
+	}
+
+	if tv, ok := info.Types[call.Fun]; ok && tv.IsType() {
+		if len(call.Args) == 1 {
+			v.convert(call.Args[0], tv.Type)
+		}
+		return
+	}
+
+	sig, ok := underlying(info.TypeOf(call.Fun)).(*types.Signature)
+	if !ok {
+		return
+	}
+	params := sig.Params()
+	for i, arg := range call.Args {
+		var t types.Type
+		switch {
+		case sig.Variadic() && i >= params.Len()-1 && !call.Ellipsis.IsValid():
+			if s, ok := params.At(params.Len() - 1).Type().(*types.Slice); ok {
+				t = s.Elem()
+			}
+		case i < params.Len():
+			t = params.At(i).Type()
+		}
+		v.convert(arg, t)
+	}
+}
+
+func (v *refVisitor) visitCompositeLit(lit *ast.CompositeLit) {
+	info := v.g.pkg.TypesInfo
+	t := info.TypeOf(lit)
+	if ptr, ok := underlying(t).(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	switch u := underlying(t).(type) {
+	case *types.Struct:
+		for i, elt := range lit.Elts {
+			if kv, ok := elt.(*ast.KeyValueExpr); ok {
+				if key, ok := kv.Key.(*ast.Ident); ok {
+					if field, ok := lint.Origin(info.Uses[key]).(*types.Var); ok {
+						v.g.writeIdents[key] = true
+						v.convert(kv.Value, field.Type())
+					}
+				}
+				continue
+			}
+			if i < u.NumFields() {
+				field := u.Field(i).Origin()
+				if v.g.isNode(field) {
+					v.g.writes[field] = append(v.g.writes[field], v.src)
+				}
+				v.convert(elt, field.Type())
+			}
+		}
+	case *types.Slice, *types.Array, *types.Map:
+		var key, elem types.Type
+		switch c := u.(type) {
+		case *types.Slice:
+			elem = c.Elem()
+		case *types.Array:
+			elem = c.Elem()
+		case *types.Map:
+			key, elem = c.Key(), c.Elem()
+		}
+		for _, elt := range lit.Elts {
+			if kv, ok := elt.(*ast.KeyValueExpr); ok {
+				if key != nil {
+					v.convert(kv.Key, key)
+				}
+				v.convert(kv.Value, elem)
+				continue
+			}
+			v.convert(elt, elem)
+		}
+	}
+}
+
+// convert records the conversion of the value of expr to the target type,
+// if the target is an interface.
+func (v *refVisitor) convert(expr ast.Expr, target types.Type) {
+	if target == nil || !types.IsInterface(target) {
+		return
+	}
+	t := v.g.pkg.TypesInfo.TypeOf(expr)
+	if t == nil || types.IsInterface(t) {
+		return
+	}
+	v.g.addConversion(v.src, t)
+}
+
+// addConversion makes reachable, from src, the methods of t that can be
+// called through an interface: exported methods (that can be called through
+// reflection) and those implementing interfaces in scope.
+func (g *refGraph) addConversion(src types.Object, t types.Type) {
+	if named := namedOf(t); named != nil && named.Obj().Pkg() == g.pkg.TypesPkg {
+		g.conversions[named.Obj()] = append(g.conversions[named.Obj()], src)
+	}
+	// exported fields can be read through reflection (e.g. by templates)
+	g.addFieldReads(src, t, true, map[types.Type]bool{})
+
+	ifaceMethods := g.interfaceMethods(t)
+	ms := g.methodSets.MethodSet(t)
+	for i := 0; i < ms.Len(); i++ {
+		m := ms.At(i).Obj()
+		if !g.isNode(m) {
+			continue
+		}
+		if _, ok := ifaceMethods[m]; ok || m.Exported() {
+			g.addRef(src, m)
+		}
+	}
+}
+
+// addAPIRoots adds as roots the exported fields and methods, including
+// promoted ones, of the types of the package that are part of its API:
+// exported types and unexported types exposed by exported declarations.
+func (g *refGraph) addAPIRoots() {
+	e := newAPIExposure(g.pkg.TypesPkg, &g.methodSets)
+	scope := g.pkg.TypesPkg.Scope()
+	for _, name := range scope.Names() {
+		if obj := scope.Lookup(name); obj.Exported() {
+			e.expose(obj.Type())
+		}
+	}
+
+	for f := range e.fields {
+		if g.isNode(f) {
+			g.roots[f] = true
+		}
+	}
+	for obj := range e.types {
+		for _, ptr := range []types.Type{obj.Type(), types.NewPointer(obj.Type())} {
+			ms := g.methodSets.MethodSet(ptr)
+			for i := 0; i < ms.Len(); i++ {
+				if m := lint.Origin(ms.At(i).Obj()); m.Exported() && g.isNode(m) {
+					g.roots[m] = true
+				}
+			}
+		}
+	}
+}
+
+// apiExposure collects the declarations of a package exposed by types of its
+// API: the named types of the package the types are made of, through their
+// underlying types, the exported and embedded fields of structs, the
+// signatures of functions and of the exported methods of named types, and
+// the methods of interfaces.
+type apiExposure struct {
+	pkg        *types.Package
+	methodSets *typeutil.MethodSetCache
+	// types are the exposed named types of the package
+	types map[*types.TypeName]bool
+	// fields are the exposed exported fields of the structs of the package
+	fields map[*types.Var]bool
+}
+
+func newAPIExposure(pkg *types.Package, methodSets *typeutil.MethodSetCache) *apiExposure {
+	return &apiExposure{pkg: pkg, methodSets: methodSets, types: map[*types.TypeName]bool{}, fields: map[*types.Var]bool{}}
+}
+
+// expose adds the declarations of the package exposed by t.
+func (e *apiExposure) expose(t types.Type) {
+	switch u := t.(type) {
+	case *types.Named:
+		obj := u.Obj()
+		if obj.Pkg() != e.pkg || e.types[obj] {
+			return
+		}
+		e.types[obj] = true
+		e.expose(u.Underlying())
+		for _, ptr := range []types.Type{u, types.NewPointer(u)} {
+			ms := e.methodSets.MethodSet(ptr)
+			for i := 0; i < ms.Len(); i++ {
+				if m := ms.At(i).Obj(); m.Exported() {
+					e.expose(m.Type())
+				}
+			}
+		}
+	case *types.Pointer:
+		e.expose(u.Elem())
+	case *types.Slice:
+		e.expose(u.Elem())
+	case *types.Array:
+		e.expose(u.Elem())
+	case *types.Chan:
+		e.expose(u.Elem())
+	case *types.Map:
+		e.expose(u.Key())
+		e.expose(u.Elem())
+	case *types.Signature:
+		for _, tuple := range []*types.Tuple{u.Params(), u.Results()} {
+			for i := 0; i < tuple.Len(); i++ {
+				e.expose(tuple.At(i).Type())
+			}
+		}
+	case *types.Struct:
+		for i := 0; i < u.NumFields(); i++ {
+			f := u.Field(i).Origin()
+			if f.Exported() && f.Pkg() == e.pkg {
+				e.fields[f] = true
+			}
+			if f.Exported() || f.Anonymous() {
+				e.expose(f.Type())
+			}
+		}
+	case *types.Interface:
+		for i := 0; i < u.NumMethods(); i++ {
+			e.expose(u.Method(i).Type())
+		}
+	}
+}
+
+// addTaggedFieldRoots adds as roots the fields having struct tags with one
+// of the configured keys (e.g. json), as they are read by encoders.
+func (g *refGraph) addTaggedFieldRoots(file *ast.File) {
+	ast.Inspect(file, func(n ast.Node) bool {
+		st, ok := n.(*ast.StructType)
+		if !ok || st.Fields == nil {
+			return true
+		}
+		for _, field := range st.Fields.List {
+			if field.Tag == nil {
+				continue
+			}
+			tag, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				continue
+			}
+			for _, key := range g.options.fieldTags {
+				if _, ok := reflect.StructTag(tag).Lookup(key); !ok {
+					continue
+				}
+				for _, name := range field.Names {
+					if obj := g.pkg.TypesInfo.Defs[name]; obj != nil {
+						g.roots[obj] = true
+					}
+				}
+				break
+			}
+		}
+		return true
+	})
+}
+
+// addFieldReads adds references from src to the fields of the structs
+// reachable from the type t, within the package.
+func (g *refGraph) addFieldReads(src types.Object, t types.Type, exportedOnly bool, seen map[types.Type]bool) {
+	switch u := t.(type) {
+	case *types.Named:
+		if seen[u] || u.Obj().Pkg() != g.pkg.TypesPkg {
+			return
+		}
+		seen[u] = true
+		g.addFieldReads(src, u.Underlying(), exportedOnly, seen)
+	case *types.Pointer:
+		g.addFieldReads(src, u.Elem(), exportedOnly, seen)
+	case *types.Slice:
+		g.addFieldReads(src, u.Elem(), exportedOnly, seen)
+	case *types.Array:
+		g.addFieldReads(src, u.Elem(), exportedOnly, seen)
+	case *types.Map:
+		g.addFieldReads(src, u.Key(), exportedOnly, seen)
+		g.addFieldReads(src, u.Elem(), exportedOnly, seen)
+	case *types.Struct:
+		for i := 0; i < u.NumFields(); i++ {
+			f := u.Field(i).Origin()
+			if exportedOnly && !f.Exported() {
+				continue
+			}
+			if g.isNode(f) {
+				g.addRef(src, f)
+				if exportedOnly {
+					g.reflected[f] = true
+				}
+			}
+			g.addFieldReads(src, f.Type(), exportedOnly, seen)
+		}
+	}
+}
+
+// writtenFrom returns true if the field is written in one of the reachable declarations.
+func (g *refGraph) writtenFrom(field types.Object, reachable map[types.Object]bool) bool {
+	for _, src := range g.writes[field] {
+		if src == nil || reachable[src] {
+			return true
+		}
+	}
+	return false
+}
+
+// interfacesInScope returns the non-empty named interfaces declared in the
+// package and those exported by its dependencies, including error.
+func (g *refGraph) interfacesInScope() []*types.TypeName {
+	if g.interfaces != nil {
+		return g.interfaces
+	}
+
+	g.interfaces = []*types.TypeName{types.Universe.Lookup("error").(*types.TypeName)}
+	seen := map[*types.Package]bool{}
+	var visit func(p *types.Package)
+	visit = func(p *types.Package) {
+		if seen[p] {
+			return
+		}
+		seen[p] = true
+		scope := p.Scope()
+		for _, name := range scope.Names() {
+			tn, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || (p != g.pkg.TypesPkg && !tn.Exported()) {
+				continue
+			}
+			if iface, ok := tn.Type().Underlying().(*types.Interface); ok && iface.NumMethods() > 0 {
+				g.interfaces = append(g.interfaces, tn)
+			}
+		}
+		for _, imp := range p.Imports() {
+			visit(imp)
+		}
+	}
+	visit(g.pkg.TypesPkg)
+
+	return g.interfaces
+}
+
+// interfaceMethods returns the methods of t, declared in the package, that
+// implement methods of interfaces in scope, along with these interfaces.
+func (g *refGraph) interfaceMethods(t types.Type) map[types.Object][]*types.TypeName {
+	result := map[types.Object][]*types.TypeName{}
+	if g.methodSets.MethodSet(t).Len() == 0 {
+		return result
+	}
+
+	for _, tn := range g.interfacesInScope() {
+		iface := tn.Type().Underlying().(*types.Interface)
+		if !types.Implements(t, iface) {
+			continue
+		}
+		for i := 0; i < iface.NumMethods(); i++ {
+			im := iface.Method(i)
+			sel := g.methodSets.MethodSet(t).Lookup(im.Pkg(), im.Name())
+			if sel == nil {
+				continue
+			}
+			if m := lint.Origin(sel.Obj()); g.isNode(m) {
+				result[m] = append(result[m], tn)
+			}
+		}
+	}
+	return result
+}
+
+// convertedFrom returns true if values of the given type are converted to
+// interfaces in one of the given declarations.
+func (g *refGraph) convertedFrom(tn *types.TypeName, reachable map[types.Object]bool) bool {
+	for _, src := range g.conversions[tn] {
+		if src == nil || reachable[src] {
+			return true
+		}
+	}
+	return false
+}
+
+func isField(obj types.Object) bool {
+	v, ok := obj.(*types.Var)
+	return ok && v.IsField()
+}
+
+func isMethod(obj types.Object) bool {
+	fn, ok := obj.(*types.Func)
+	return ok && fn.Type().(*types.Signature).Recv() != nil
+}
+
+func namedOf(t types.Type) *types.Named {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, _ := t.(*types.Named)
+	return named
+}
+
+func underlying(t types.Type) types.Type {
+	if t == nil {
+		return nil
+	}
+	return t.Underlying()
+}
+
+// reachable returns the set of declarations reachable from the roots.
+func (g *refGraph) reachable() map[types.Object]bool {
+	return g.reach(false)
+}
+
+// reachableFromProduction returns the set of declarations reachable from the
+// roots without going through declarations of test files.
+func (g *refGraph) reachableFromProduction() map[types.Object]bool {
+	return g.reach(true)
+}
+
+func (g *refGraph) reach(skipTests bool) map[types.Object]bool {
+	result := map[types.Object]bool{}
+	pending := append([]types.Object{}, g.refs[nil]...)
+	for obj := range g.roots {
+		pending = append(pending, obj)
+	}
+	for len(pending) > 0 {
+		obj := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if result[obj] || skipTests && g.inTests(obj) {
+			continue
+		}
+		result[obj] = true
+		pending = append(pending, g.refs[obj]...)
+	}
+	return result
+}
+
+// inTests returns true if the object is declared in a test file, or is the
+// context of the references made from test files.
+func (g *refGraph) inTests(obj types.Object) bool {
+	if obj == g.testContext {
+		return true
+	}
+	return len(g.testFiles) > 0 && g.testFiles[g.pkg.Fset().Position(obj.Pos()).Filename]
+}
+
+// deadChain returns a chain of unreachable declarations ending with obj,
+// where each declaration is referred to by the previous one.
+// The chain starts with a declaration never referred to, or closes a cycle.
+func (g *refGraph) deadChain(obj types.Object) []types.Object {
+	chain := []types.Object{obj}
+	inChain := map[types.Object]bool{obj: true}
+	for current := obj; ; {
+		referrers := g.sortedReferrers(current)
+		if len(referrers) == 0 {
+			break
+		}
+		next := referrers[0]
+		for _, r := range referrers {
+			if !inChain[r] {
+				next = r
+				break
+			}
+		}
+		if inChain[next] {
+			break // cycle
+		}
+		chain = append([]types.Object{next}, chain...)
+		inChain[next] = true
+		current = next
+	}
+	return chain
+}
+
+func (g *refGraph) sortedReferrers(obj types.Object) []types.Object {
+	result := []types.Object{}
+	seen := map[types.Object]bool{}
+	for _, r := range g.referrers[obj] {
+		if r != nil && r != g.testContext && !seen[r] {
+			seen[r] = true
+			result = append(result, r)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Pos() < result[j].Pos() })
+	return result
+}
+
+// chainNames returns the names of the objects in the chain.
+func chainNames(chain []types.Object) string {
+	names := make([]string, len(chain))
+	for i, obj := range chain {
+		names[i] = obj.Name()
+	}
+	return strings.Join(names, " -> ")
+}
+
+func hasCall(exprs []ast.Expr) bool {
+	for _, e := range exprs {
+		found := false
+		ast.Inspect(e, func(n ast.Node) bool {
+			if _, ok := n.(*ast.CallExpr); ok {
+				found = true
+			}
+			return !found
+		})
+		if found {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,382 @@
+package rule
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+
+	"github.com/chavacava/gusano/lint"
+)
+
+// SimplifiableSignatureRule lints the results of functions and methods that
+// no caller uses, and the parameters to which every caller passes the same
+// constant value.
+// Unexported functions are analyzed with the call sites of their package;
+// with the module option, exported functions are analyzed too, with the call
+// sites of all the loaded packages.
+// Functions used otherwise than by calling them (e.g. passed as values) and
+// methods that can implement an interface are not analyzed, as their
+// signature is constrained.
+type SimplifiableSignatureRule struct{}
+
+// simplifiableSignatureOptions are the options of the rule, given as a table argument:
+//
+//	[rule.simplifiable-signature]
+//	arguments = [{ module = true, minCallSites = 3 }]
+type simplifiableSignatureOptions struct {
+	// module enables the analysis of exported functions
+	module bool
+	// minCallSites is the number of call sites required to report a constant parameter
+	minCallSites int
+}
+
+func (r *SimplifiableSignatureRule) parseOptions(arguments lint.Arguments) (simplifiableSignatureOptions, error) {
+	options := simplifiableSignatureOptions{minCallSites: 2}
+	err := parseTables(arguments, r.Name(), func(option string, value interface{}) error {
+		var err error
+		switch option {
+		case "module":
+			options.module, err = toBool(value)
+		case "minCallSites":
+			options.minCallSites, err = toInt(value, 1)
+		default:
+			return errUnknownOption
+		}
+		return err
+	})
+	return options, err
+}
+
+// CheckArguments checks the arguments of the rule.
+func (r *SimplifiableSignatureRule) CheckArguments(arguments lint.Arguments) error {
+	_, err := r.parseOptions(arguments)
+	return err
+}
+
+// callSite is a call to a function.
+type callSite struct {
+	pkg  *lint.Package
+	call *ast.CallExpr
+	// argOffset is the index of the first argument matching a parameter
+	// (1 for method expressions, whose first argument is the receiver)
+	argOffset int
+	// discarded is true if the results of the call are not used at all
+	discarded bool
+	// blanks tells, for calls assigned to variables, the results assigned to
+	// the blank identifier
+	blanks []bool
+}
+
+func (s callSite) usesResult(i int) bool {
+	if s.discarded {
+		return false
+	}
+	return s.blanks == nil || i >= len(s.blanks) || !s.blanks[i]
+}
+
+// ApplyToPackage applies the rule to given package.
+func (r *SimplifiableSignatureRule) ApplyToPackage(pkg *lint.Package, arguments lint.Arguments, failures chan lint.Failure) {
+	if pkg.TypesInfo == nil {
+		return
+	}
+	options, _ := r.parseOptions(arguments) // checked by CheckArguments
+	sites := callSitesOf(pkg)
+	ifaceMethods := interfaceMethodNames([]*lint.Package{pkg})
+
+	for _, id := range sortedDefs(pkg) {
+		fn, ok := pkg.TypesInfo.Defs[id].(*types.Func)
+		if !ok || fn.Exported() || !r.isCandidate(fn, ifaceMethods) {
+			continue
+		}
+
+		calls := []callSite{}
+		for _, use := range pkg.UsesOf(fn) {
+			site, ok := sites[use]
+			if !ok {
+				calls = nil // the function is used as a value
+				break
+			}
+			calls = append(calls, site)
+		}
+		r.check(fn, pkg.Fset().Position, calls, options, failures)
+	}
+}
+
+// ApplyToModule applies the rule to the exported functions of the packages of
+// the module, if the module option is set.
+func (r *SimplifiableSignatureRule) ApplyToModule(module *lint.Module, arguments lint.Arguments, failures chan lint.Failure) {
+	options, _ := r.parseOptions(arguments) // checked by CheckArguments
+	if !options.module {
+		return
+	}
+	ifaceMethods := interfaceMethodNames(module.Packages)
+	sites := map[*lint.Package]map[*ast.Ident]callSite{}
+	sitesOf := func(pkg *lint.Package) map[*ast.Ident]callSite {
+		if _, ok := sites[pkg]; !ok {
+			sites[pkg] = callSitesOf(pkg)
+		}
+		return sites[pkg]
+	}
+
+	checked := map[string]bool{}
+	for _, pkg := range module.Packages {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for _, id := range sortedDefs(pkg) {
+			fn, ok := pkg.TypesInfo.Defs[id].(*types.Func)
+			if !ok || !fn.Exported() || !r.isCandidate(fn, ifaceMethods) {
+				continue
+			}
+			key := lint.ObjectKey(fn)
+			if key == "" || checked[key] {
+				continue
+			}
+			checked[key] = true
+
+			calls := []callSite{}
+			seen := map[token.Position]bool{}
+			for _, use := range module.UsesOf(fn) {
+				site, ok := sitesOf(use.Pkg)[use.Ident]
+				if !ok {
+					calls = nil
+					break
+				}
+				if position := use.Pkg.Fset().Position(use.Ident.Pos()); !seen[position] {
+					seen[position] = true // file shared by several package variants
+					calls = append(calls, site)
+				}
+			}
+			r.check(fn, pkg.Fset().Position, calls, options, failures)
+		}
+	}
+}
+
+// isCandidate returns true if the signature of the function is not
+// constrained by something else than its call sites.
+func (r *SimplifiableSignatureRule) isCandidate(fn *types.Func, ifaceMethods map[string]bool) bool {
+	if fn.Name() == "init" || fn.Name() == "main" || fn.Name() == "_" {
+		return false
+	}
+	if fn.Type().(*types.Signature).Recv() != nil && ifaceMethods[fn.Name()] {
+		return false
+	}
+	return true
+}
+
+// check reports the results of fn unused by all the calls, and the
+// parameters receiving the same constant in all the calls.
+func (r *SimplifiableSignatureRule) check(fn *types.Func, position func(token.Pos) token.Position, calls []callSite, options simplifiableSignatureOptions, failures chan lint.Failure) {
+	if len(calls) == 0 {
+		return
+	}
+	sig := fn.Type().(*types.Signature)
+	kind := "function"
+	if sig.Recv() != nil {
+		kind = "method"
+	}
+
+	results := sig.Results()
+	for i := 0; i < results.Len(); i++ {
+		used := false
+		for _, c := range calls {
+			if c.usesResult(i) {
+				used = true
+				break
+			}
+		}
+		if used {
+			continue
+		}
+		v := results.At(i)
+		failures <- lint.Failure{
+			RuleName:   r.Name(),
+			Confidence: 1,
+			Failure:    fmt.Sprintf("result %s (%s) of %s %s is never used by its callers", varLabel(v, i), types.TypeString(v.Type(), types.RelativeTo(fn.Pkg())), kind, fn.Name()),
+			Position:   lint.FailurePosition{Start: position(v.Pos())},
+		}
+	}
+
+	if len(calls) < options.minCallSites {
+		return
+	}
+	params := sig.Params()
+	for i := 0; i < params.Len(); i++ {
+		if sig.Variadic() && i == params.Len()-1 {
+			break
+		}
+		v := params.At(i)
+		if v.Name() == "_" {
+			continue
+		}
+		value, ok := "", true
+		for j, c := range calls {
+			arg, isConst := constantArg(c, i)
+			if !isConst || j > 0 && arg != value {
+				ok = false
+				break
+			}
+			value = arg
+		}
+		if !ok {
+			continue
+		}
+		failures <- lint.Failure{
+			RuleName:   r.Name(),
+			Confidence: 0.8,
+			Failure:    fmt.Sprintf("parameter %s of %s %s always receives %s", varLabel(v, i), kind, fn.Name(), value),
+			Position:   lint.FailurePosition{Start: position(v.Pos())},
+		}
+	}
+}
+
+// constantArg returns the constant value, as a string, of the argument of
+// the call matching the i-th parameter.
+func constantArg(c callSite, i int) (string, bool) {
+	i += c.argOffset
+	if i >= len(c.call.Args) || len(c.call.Args) == 1 && c.argOffset == 0 && isTuple(c.pkg, c.call.Args[0]) {
+		return "", false
+	}
+	tv, ok := c.pkg.TypesInfo.Types[c.call.Args[i]]
+	switch {
+	case !ok:
+		return "", false
+	case tv.IsNil():
+		return "nil", true
+	case tv.Value != nil:
+		return tv.Value.ExactString(), true
+	}
+	return "", false
+}
+
+func isTuple(pkg *lint.Package, expr ast.Expr) bool {
+	_, ok := pkg.TypesInfo.TypeOf(expr).(*types.Tuple)
+	return ok
+}
+
+// varLabel returns the name of a parameter or result, or its position if it is unnamed.
+func varLabel(v *types.Var, i int) string {
+	if v.Name() != "" && v.Name() != "_" {
+		return v.Name()
+	}
+	return strconv.Itoa(i + 1)
+}
+
+// callSitesOf returns the call sites of the package, indexed by the
+// identifier of the called function.
+func callSitesOf(pkg *lint.Package) map[*ast.Ident]callSite {
+	result := map[*ast.Ident]callSite{}
+	for _, file := range pkg.Files() {
+		var stack []ast.Node
+		ast.Inspect(file.AST, func(n ast.Node) bool {
+			if n == nil {
+				stack = stack[:len(stack)-1]
+				return true
+			}
+			if call, ok := n.(*ast.CallExpr); ok {
+				if id, offset := calleeIdent(pkg, call); id != nil {
+					site := callSite{pkg: pkg, call: call, argOffset: offset}
+					site.discarded, site.blanks = resultsUsage(call, stack)
+					result[id] = site
+				}
+			}
+			stack = append(stack, n)
+			return true
+		})
+	}
+	return result
+}
+
+// calleeIdent returns the identifier of the function called by call, if the
+// function is called by its name (possibly instantiated), and the index of the argument matching its
+// first parameter.
+func calleeIdent(pkg *lint.Package, call *ast.CallExpr) (*ast.Ident, int) {
+	fun := call.Fun
+	for done := false; !done; {
+		switch f := fun.(type) {
+		case *ast.ParenExpr:
+			fun = f.X
+		case *ast.IndexExpr: // explicit instantiation f[T](...)
+			fun = f.X
+		case *ast.IndexListExpr:
+			fun = f.X
+		default:
+			done = true
+		}
+	}
+	switch f := fun.(type) {
+	case *ast.Ident:
+		return f, 0
+	case *ast.SelectorExpr:
+		if sel, ok := pkg.TypesInfo.Selections[f]; ok && sel.Kind() == types.MethodExpr {
+			return f.Sel, 1
+		}
+		return f.Sel, 0
+	}
+	return nil, 0
+}
+
+// resultsUsage tells how the results of the call are used, given the stack of
+// its ancestors: not at all, or assigned to variables, some of them blank.
+// It returns false and nil when all the results are used.
+func resultsUsage(call *ast.CallExpr, stack []ast.Node) (bool, []bool) {
+	if len(stack) == 0 {
+		return false, nil
+	}
+	blanks := func(exprs []ast.Expr) []bool {
+		result := make([]bool, len(exprs))
+		for i, e := range exprs {
+			id, ok := e.(*ast.Ident)
+			result[i] = ok && id.Name == "_"
+		}
+		return result
+	}
+
+	switch parent := stack[len(stack)-1].(type) {
+	case *ast.ExprStmt:
+		return true, nil
+	case *ast.GoStmt, *ast.DeferStmt:
+		return true, nil
+	case *ast.AssignStmt:
+		if len(parent.Rhs) == 1 {
+			return false, blanks(parent.Lhs)
+		}
+		for i, rhs := range parent.Rhs {
+			if rhs == call && i < len(parent.Lhs) {
+				return false, blanks(parent.Lhs[i : i+1])
+			}
+		}
+	case *ast.ValueSpec:
+		names := make([]ast.Expr, len(parent.Names))
+		for i, name := range parent.Names {
+			names[i] = name
+		}
+		if len(parent.Values) == 1 {
+			return false, blanks(names)
+		}
+		for i, value := range parent.Values {
+			if value == call && i < len(names) {
+				return false, blanks(names[i : i+1])
+			}
+		}
+	}
+	return false, nil
+}
+
+// ApplyToFile applies the rule to given file.
+func (r *SimplifiableSignatureRule) ApplyToFile(file *lint.File, arguments lint.Arguments) []lint.Failure {
+	return nil
+}
+
+// Name returns the rule name.
+func (r *SimplifiableSignatureRule) Name() string {
+	return "simplifiable-signature"
+}
+
+// RequiresConsensus returns true because call sites can differ from one
+// build configuration to another.
+func (r *SimplifiableSignatureRule) RequiresConsensus() bool {
+	return true
+}
@@ -0,0 +1,353 @@
+package rule
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+
+	"github.com/chavacava/gusano/lint"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// UnhandledErrorRule lints the errors returned by function calls that are not
+// handled: calls used as statements, dropping their error result, and errors
+// assigned to local variables that are overwritten, or go out of scope,
+// before being checked.
+// Errors assigned to the blank identifier are explicitly ignored and are not
+// reported, nor are the calls of functions of the package that provably
+// always return a nil error, according to a summary of the returns of each
+// function of the package.
+type UnhandledErrorRule struct{}
+
+// unhandledErrorOptions are the options of the rule, given as a table argument:
+//
+//	[rule.unhandled-error]
+//	arguments = [{ ignore = ["fmt.Fprintf", "(*example.com/mod/log.Logger).Write"] }]
+type unhandledErrorOptions struct {
+	// ignore are the normalized qualified names of the functions whose errors
+	// do not need to be handled
+	ignore map[string]bool
+}
+
+// defaultIgnoredErrors are the functions whose error result is documented to
+// be always nil, or is not worth handling.
+var defaultIgnoredErrors = []string{
+	"fmt.Print", "fmt.Printf", "fmt.Println",
+	"bytes.Buffer.Write", "bytes.Buffer.WriteByte", "bytes.Buffer.WriteRune", "bytes.Buffer.WriteString",
+	"strings.Builder.Write", "strings.Builder.WriteByte", "strings.Builder.WriteRune", "strings.Builder.WriteString",
+}
+
+// hashTypes are the hash.Hash interfaces, whose Write method is documented to
+// never return an error.
+var hashTypes = map[string]bool{"hash.Hash": true, "hash.Hash32": true, "hash.Hash64": true}
+
+// bufferTypes are the writers whose Write method always returns a nil error,
+// that make the fmt.Fprint functions writing into them always succeed.
+var bufferTypes = map[string]bool{"*bytes.Buffer": true, "*strings.Builder": true}
+
+// alwaysNilError returns true if the error returned by the call of fn is
+// documented to be always nil because of the value it writes into: the Write
+// method of a hash.Hash, or fmt.Fprint, fmt.Fprintf or fmt.Fprintln writing
+// into a *bytes.Buffer or a *strings.Builder.
+// recv is the type of the receiver of the call and writer the one of its first
+// argument, nil if there are none.
+func alwaysNilError(fn *types.Func, recv, writer types.Type) bool {
+	switch qualifiedName(fn) {
+	case "fmt.Fprint", "fmt.Fprintf", "fmt.Fprintln":
+		return writer != nil && bufferTypes[types.TypeString(writer, nil)]
+	}
+	return fn.Name() == "Write" && recv != nil && hashTypes[types.TypeString(recv, nil)]
+}
+
+func (r *UnhandledErrorRule) parseOptions(arguments lint.Arguments) (unhandledErrorOptions, error) {
+	options := unhandledErrorOptions{ignore: map[string]bool{}}
+	ignore := defaultIgnoredErrors
+	err := parseTables(arguments, r.Name(), func(option string, value interface{}) error {
+		switch option {
+		case "ignore":
+			names, err := toStrings(value)
+			ignore = append(ignore, names...)
+			return err
+		default:
+			return errUnknownOption
+		}
+	})
+	for _, name := range ignore {
+		options.ignore[normalizeSymbol(name)] = true
+	}
+	return options, err
+}
+
+// CheckArguments checks the arguments of the rule.
+func (r *UnhandledErrorRule) CheckArguments(arguments lint.Arguments) error {
+	_, err := r.parseOptions(arguments)
+	return err
+}
+
+// errorType is the type of the predeclared error interface.
+var errorType = types.Universe.Lookup("error").Type()
+
+// errorResults returns the indexes of the results of type error of the
+// signature.
+func errorResults(sig *types.Signature) []int {
+	var result []int
+	for i := 0; i < sig.Results().Len(); i++ {
+		if types.Identical(sig.Results().At(i).Type(), errorType) {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// nilErrors maps the functions of the package to the indexes of their error
+// results that are nil on every return.
+type nilErrors map[*types.Func]map[int]bool
+
+// handles returns true if the error results of the function need no handling.
+func (n nilErrors) handles(fn *types.Func, indexes []int) bool {
+	for _, i := range indexes {
+		if !n[fn][i] {
+			return false
+		}
+	}
+	return true
+}
+
+// nilErrorResults summarizes the returns of the functions of the package:
+// an error result is nil if every return of the function returns nil, or the
+// nil error result of a static call of a function of the package.
+// The summaries are computed as a greatest fixpoint, starting with all the
+// error results considered nil, so that recursive functions can be nil.
+func nilErrorResults(fns []*ssa.Function) nilErrors {
+	summaries := map[*ssa.Function]map[int]bool{}
+	for _, fn := range fns {
+		if fn.Synthetic != "" || fn.Object() == nil {
+			continue
+		}
+		for _, i := range errorResults(fn.Signature) {
+			if summaries[fn] == nil {
+				summaries[fn] = map[int]bool{}
+			}
+			summaries[fn][i] = true
+		}
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for fn, indexes := range summaries {
+			for i := range indexes {
+				if !returnsNil(fn, i, summaries) {
+					delete(indexes, i)
+					changed = true
+				}
+			}
+		}
+	}
+
+	result := nilErrors{}
+	for fn, indexes := range summaries {
+		result[fn.Object().(*types.Func)] = indexes
+	}
+	return result
+}
+
+// returnsNil returns true if every return of the function returns a nil
+// value as its i-th result.
+func returnsNil(fn *ssa.Function, i int, summaries map[*ssa.Function]map[int]bool) bool {
+	for _, b := range fn.Blocks {
+		if ret, ok := b.Instrs[len(b.Instrs)-1].(*ssa.Return); ok && !isNilValue(ret.Results[i], summaries, map[ssa.Value]bool{}) {
+			return false
+		}
+	}
+	return true
+}
+
+func isNilValue(v ssa.Value, summaries map[*ssa.Function]map[int]bool, seen map[ssa.Value]bool) bool {
+	if seen[v] {
+		return true
+	}
+	seen[v] = true
+	switch v := v.(type) {
+	case *ssa.Const:
+		return v.IsNil()
+	case *ssa.Phi:
+		for _, edge := range v.Edges {
+			if !isNilValue(edge, summaries, seen) {
+				return false
+			}
+		}
+		return true
+	case *ssa.Call:
+		callee := v.Call.StaticCallee()
+		return callee != nil && summaries[callee][0]
+	case *ssa.Extract:
+		call, ok := v.Tuple.(*ssa.Call)
+		if !ok {
+			return false
+		}
+		callee := call.Call.StaticCallee()
+		return callee != nil && summaries[callee][v.Index]
+	}
+	return false
+}
+
+// ApplyToPackage applies the rule to given package.
+func (r *UnhandledErrorRule) ApplyToPackage(pkg *lint.Package, arguments lint.Arguments, failures chan lint.Failure) {
+	options, _ := r.parseOptions(arguments) // checked by CheckArguments
+	ssaPkg := pkg.SSA()
+	if ssaPkg == nil {
+		return
+	}
+	fns := packageFunctions(ssaPkg)
+	nils := nilErrorResults(fns)
+
+	for _, file := range pkg.Files() {
+		ast.Inspect(file.AST, func(n ast.Node) bool {
+			stmt, ok := n.(*ast.ExprStmt)
+			if !ok {
+				return true
+			}
+			call, ok := ast.Unparen(stmt.X).(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			tv, ok := pkg.TypesInfo.Types[call.Fun]
+			if !ok || tv.IsType() || tv.IsBuiltin() {
+				return true
+			}
+			sig, ok := tv.Type.Underlying().(*types.Signature)
+			if !ok {
+				return true
+			}
+			indexes := errorResults(sig)
+			if len(indexes) == 0 {
+				return true
+			}
+			name := types.ExprString(call.Fun)
+			if fn, ok := typeutil.Callee(pkg.TypesInfo, call).(*types.Func); ok {
+				fn = fn.Origin()
+				recv, writer := callTypes(pkg.TypesInfo, call)
+				if options.ignore[qualifiedName(fn)] || nils.handles(fn, indexes) || alwaysNilError(fn, recv, writer) {
+					return true
+				}
+				name = fn.FullName()
+			}
+			failures <- lint.Failure{
+				RuleName:   r.Name(),
+				Category:   "errors",
+				Confidence: 1,
+				Failure:    fmt.Sprintf("error returned by %s is not handled", name),
+				Node:       call,
+				Position:   lint.FailurePosition{Start: pkg.Fset().Position(call.Pos()), End: pkg.Fset().Position(call.End())},
+			}
+			return true
+		})
+	}
+
+	targets := assignmentTargets(pkg)
+	inMemory := allocatedVars(fns)
+	for _, fn := range fns {
+		if fn.Synthetic != "" {
+			continue
+		}
+		for _, store := range deadStores(fn, targets, inMemory) {
+			if !types.Identical(store.v.Type(), errorType) {
+				continue
+			}
+			call, index, ok := errorSource(store.def.X)
+			if !ok {
+				continue
+			}
+			name := "a function value"
+			if callee := calleeOf(call); callee != nil {
+				callee = callee.Origin()
+				recv, writer := ssaCallTypes(call)
+				if options.ignore[qualifiedName(callee)] || nils.handles(callee, []int{index}) || alwaysNilError(callee, recv, writer) {
+					continue
+				}
+				name = callee.FullName()
+			}
+			failures <- lint.Failure{
+				RuleName:   r.Name(),
+				Category:   "errors",
+				Confidence: 1,
+				Failure:    fmt.Sprintf("error returned by %s is assigned to %s but never checked", name, store.v.Name()),
+				Node:       store.def.Expr,
+				Position:   lint.FailurePosition{Start: pkg.Fset().Position(store.def.Expr.Pos()), End: pkg.Fset().Position(store.def.Expr.End())},
+			}
+		}
+	}
+}
+
+// callTypes returns the types of the receiver and of the first argument of
+// the call, nil if there are none.
+func callTypes(info *types.Info, call *ast.CallExpr) (recv, writer types.Type) {
+	if sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr); ok && info.Selections[sel] != nil {
+		recv = info.TypeOf(sel.X)
+	}
+	if len(call.Args) > 0 {
+		writer = info.TypeOf(call.Args[0])
+	}
+	return recv, writer
+}
+
+// errorSource returns the call producing the value, and the index of its
+// result, if the value is the result of a call.
+func errorSource(v ssa.Value) (*ssa.CallCommon, int, bool) {
+	index := 0
+	if extract, ok := v.(*ssa.Extract); ok {
+		v, index = extract.Tuple, extract.Index
+	}
+	call, ok := v.(*ssa.Call)
+	if !ok {
+		return nil, 0, false
+	}
+	return call.Common(), index, true
+}
+
+// calleeOf returns the function called, if known.
+func calleeOf(call *ssa.CallCommon) *types.Func {
+	if call.IsInvoke() {
+		return call.Method
+	}
+	if callee := call.StaticCallee(); callee != nil {
+		if fn, ok := callee.Object().(*types.Func); ok {
+			return fn
+		}
+	}
+	if fn, ok := call.Value.(*ssa.Function); ok && fn.Object() != nil {
+		return fn.Object().(*types.Func)
+	}
+	return nil
+}
+
+// ssaCallTypes returns the types of the receiver and of the first argument of
+// the call, before their conversion to an interface, nil if there are none.
+func ssaCallTypes(call *ssa.CallCommon) (recv, writer types.Type) {
+	args := call.Args
+	switch {
+	case call.IsInvoke():
+		recv = call.Value.Type()
+	case call.Signature().Recv() != nil && len(args) > 0:
+		recv, args = args[0].Type(), args[1:]
+	}
+	if len(args) > 0 {
+		arg := args[0]
+		if mi, ok := arg.(*ssa.MakeInterface); ok {
+			arg = mi.X
+		}
+		writer = arg.Type()
+	}
+	return recv, writer
+}
+
+// ApplyToFile applies the rule to given file.
+func (r *UnhandledErrorRule) ApplyToFile(file *lint.File, arguments lint.Arguments) []lint.Failure {
+	return nil
+}
+
+// Name returns the rule name.
+func (r *UnhandledErrorRule) Name() string {
+	return "unhandled-error"
+}
@@ -0,0 +1,466 @@
+package rule
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/chavacava/gusano/lint"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// UnnecessaryExportRule lints exported functions, types, methods, fields and
+// constants that are referenced only from inside their declaring package,
+// and suggests to unexport them by renaming every reference.
+// The types and fields exposed by the declarations used by other packages,
+// and the interfaces implemented by types of other packages, are used too.
+// Packages whose API is public (e.g. libraries used by other modules) can be
+// excluded, except their internal packages that can not be imported from
+// other modules.
+type UnnecessaryExportRule struct{}
+
+// unnecessaryExportOptions are the options of the rule, given as a table argument:
+//
+//	[rule.unnecessary-export]
+//	arguments = [{ public = ["example.com/mod/api/..."] }]
+type unnecessaryExportOptions struct {
+	// public are the patterns of the packages whose exported API is public
+	public []string
+}
+
+func (r *UnnecessaryExportRule) parseOptions(arguments lint.Arguments) (unnecessaryExportOptions, error) {
+	options := unnecessaryExportOptions{}
+	err := parseTables(arguments, r.Name(), func(option string, value interface{}) error {
+		var err error
+		switch option {
+		case "public":
+			options.public, err = toStrings(value)
+		default:
+			return errUnknownOption
+		}
+		return err
+	})
+	return options, err
+}
+
+// CheckArguments checks the arguments of the rule.
+func (r *UnnecessaryExportRule) CheckArguments(arguments lint.Arguments) error {
+	_, err := r.parseOptions(arguments)
+	return err
+}
+
+// isPublic returns true if the package matches one of the public patterns
+// and is not internal. Patterns are package paths, optionally ending with
+// "/..." to match the packages under a path.
+func (o unnecessaryExportOptions) isPublic(path string) bool {
+	if strings.Contains("/"+path+"/", "/internal/") {
+		return false
+	}
+	for _, pattern := range o.public {
+		if matchesPackage(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyToModule applies the rule to the packages of the module.
+func (r *UnnecessaryExportRule) ApplyToModule(module *lint.Module, arguments lint.Arguments, failures chan lint.Failure) {
+	options, _ := r.parseOptions(arguments) // checked by CheckArguments
+	ifaceMethods := interfaceMethodNames(module.Packages)
+	embedded := embeddedTypes(module)
+
+	reported := map[string]bool{}
+	for _, pkg := range module.Packages {
+		if pkg.TypesPkg == nil || pkg.TypesInfo == nil || options.isPublic(pkg.TypesPkg.Path()) {
+			continue
+		}
+
+		roots := directiveRoots(pkg)
+		exposed := exposedDecls(module, pkg)
+		var graph *refGraph
+		for _, id := range sortedDefs(pkg) {
+			obj := pkg.TypesInfo.Defs[id]
+			kind := exportKind(obj, pkg)
+			if kind == "" || roots[obj] {
+				continue
+			}
+			position := pkg.Fset().Position(id.Pos())
+			if strings.HasSuffix(position.Filename, "_test.go") {
+				continue
+			}
+			key := lint.ObjectKey(obj)
+			if key == "" || reported[key] {
+				continue
+			}
+			reported[key] = true
+
+			if exposed[obj] {
+				continue
+			}
+
+			switch kind {
+			case "type":
+				if embedded[key] {
+					continue // renaming the type would rename the embedded fields
+				}
+				if implementedOutside(module, obj.(*types.TypeName)) {
+					continue // the interface is part of the contract with other packages
+				}
+			case "method":
+				if ifaceMethods[obj.Name()] {
+					continue // the method can be needed to implement an interface
+				}
+			}
+			if kind == "method" || kind == "field" {
+				if graph == nil {
+					defaults, _ := (&UnusedSymbolRule{}).parseOptions(nil)
+					graph = newRefGraph(pkg, defaults)
+				}
+				if !r.isRenamableMember(obj, graph) {
+					continue
+				}
+			}
+
+			confidence := 1.0
+			if kind == "method" || kind == "field" {
+				confidence = 0.8 // members can be accessed through reflection
+			}
+			newName := unexportedName(obj.Name())
+			failures <- lint.Failure{
+				RuleName:     r.Name(),
+				Category:     "api",
+				Confidence:   confidence,
+				Failure:      fmt.Sprintf("exported %s %s is not used outside its package, it could be unexported", kind, obj.Name()),
+				Node:         id,
+				Position:     lint.FailurePosition{Start: position},
+				SuggestedFix: renameFix(module, obj, newName),
+			}
+		}
+	}
+}
+
+// isRenamableMember returns false if the method or field can be accessed
+// through reflection or interfaces, because values of its type are converted
+// to interfaces, or if its struct has tags.
+func (r *UnnecessaryExportRule) isRenamableMember(obj types.Object, graph *refGraph) bool {
+	var owner types.Type
+	if fn, ok := obj.(*types.Func); ok {
+		owner = fn.Type().(*types.Signature).Recv().Type()
+	} else {
+		if graph.reflected[obj] {
+			return false
+		}
+		owner = fieldOwner(obj.(*types.Var), graph.pkg.TypesPkg)
+		if owner == nil {
+			return false
+		}
+		if st, ok := owner.Underlying().(*types.Struct); ok {
+			for i := 0; i < st.NumFields(); i++ {
+				if st.Tag(i) != "" {
+					return false
+				}
+			}
+		}
+	}
+
+	named := namedOf(owner)
+	return named != nil && len(graph.conversions[named.Obj()]) == 0
+}
+
+// fieldOwner returns the named type, declared at package level, whose struct declares the field.
+func fieldOwner(field *types.Var, pkg *types.Package) types.Type {
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		tn, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok {
+			continue
+		}
+		if st, ok := tn.Type().Underlying().(*types.Struct); ok {
+			for i := 0; i < st.NumFields(); i++ {
+				if st.Field(i) == field {
+					return tn.Type()
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// exportKind returns the kind of the exported object if it is subject to the
+// rule, or "" otherwise.
+func exportKind(obj types.Object, pkg *lint.Package) string {
+	if obj == nil || !obj.Exported() || obj.Pkg() != pkg.TypesPkg {
+		return ""
+	}
+	atPackageLevel := obj.Parent() == pkg.TypesPkg.Scope()
+	switch o := obj.(type) {
+	case *types.Func:
+		if o.Type().(*types.Signature).Recv() != nil {
+			return "method"
+		}
+		return "function"
+	case *types.TypeName:
+		if atPackageLevel {
+			return "type"
+		}
+	case *types.Const:
+		if atPackageLevel {
+			return "const"
+		}
+	case *types.Var:
+		if o.IsField() && !o.Anonymous() {
+			return "field"
+		}
+	}
+	return ""
+}
+
+// usedOutside returns true if one of the references is made from another package
+// than the one declaring obj (external test packages included).
+func usedOutside(obj types.Object, refs []lint.Ref) bool {
+	for _, ref := range refs {
+		if ref.Pkg.TypesPkg.Path() != obj.Pkg().Path() {
+			return true
+		}
+	}
+	return false
+}
+
+// exposedDecls returns the exported declarations of the package that other
+// packages use: the ones they refer to, and the types and fields exposed by
+// them, like the type of the result of an exported function or the fields
+// of an exported type.
+func exposedDecls(module *lint.Module, pkg *lint.Package) map[types.Object]bool {
+	var methodSets typeutil.MethodSetCache
+	e := newAPIExposure(pkg.TypesPkg, &methodSets)
+	result := map[types.Object]bool{}
+	for _, obj := range pkg.TypesInfo.Defs {
+		if obj == nil || !obj.Exported() || result[obj] {
+			continue
+		}
+		if usedOutside(obj, module.UsesOf(obj)) {
+			result[obj] = true
+			e.expose(obj.Type())
+		}
+	}
+	for obj := range e.types {
+		result[obj] = true
+	}
+	for f := range e.fields {
+		result[f] = true
+	}
+	return result
+}
+
+// implementedOutside returns true if obj is an interface implemented by a
+// type declared in another package of the module that imports it.
+func implementedOutside(module *lint.Module, obj *types.TypeName) bool {
+	if iface, ok := obj.Type().Underlying().(*types.Interface); !ok || iface.NumMethods() == 0 {
+		return false
+	}
+	for _, other := range module.Packages {
+		if other.TypesPkg == nil || other.TypesPkg.Path() == obj.Pkg().Path() {
+			continue
+		}
+		// the interface as seen by the other package, that can be type
+		// checked separately
+		var iface *types.Interface
+		for _, imp := range other.TypesPkg.Imports() {
+			if imp.Path() == obj.Pkg().Path() {
+				if tn, ok := imp.Scope().Lookup(obj.Name()).(*types.TypeName); ok {
+					iface, _ = tn.Type().Underlying().(*types.Interface)
+				}
+			}
+		}
+		if iface == nil {
+			continue
+		}
+		scope := other.TypesPkg.Scope()
+		for _, name := range scope.Names() {
+			tn, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || tn.IsAlias() || types.IsInterface(tn.Type()) {
+				continue
+			}
+			if named, ok := tn.Type().(*types.Named); ok && named.TypeParams().Len() > 0 {
+				continue
+			}
+			if types.Implements(tn.Type(), iface) || types.Implements(types.NewPointer(tn.Type()), iface) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// interfaceMethodNames returns the names of the methods of the interfaces
+// declared in the given packages and in their dependencies.
+func interfaceMethodNames(pkgs []*lint.Package) map[string]bool {
+	result := map[string]bool{"Error": true}
+	seen := map[*types.Package]bool{}
+	var visit func(p *types.Package)
+	visit = func(p *types.Package) {
+		if seen[p] {
+			return
+		}
+		seen[p] = true
+		scope := p.Scope()
+		for _, name := range scope.Names() {
+			if iface, ok := scope.Lookup(name).Type().Underlying().(*types.Interface); ok {
+				for i := 0; i < iface.NumMethods(); i++ {
+					result[iface.Method(i).Name()] = true
+				}
+			}
+		}
+		for _, imp := range p.Imports() {
+			visit(imp)
+		}
+	}
+	for _, pkg := range pkgs {
+		if pkg.TypesPkg != nil {
+			visit(pkg.TypesPkg)
+		}
+	}
+	return result
+}
+
+// embeddedTypes returns the keys of the types embedded in structs of the module.
+func embeddedTypes(module *lint.Module) map[string]bool {
+	result := map[string]bool{}
+	for _, pkg := range module.Packages {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for _, obj := range pkg.TypesInfo.Defs {
+			if v, ok := obj.(*types.Var); ok && v.Anonymous() {
+				if named := namedOf(v.Type()); named != nil {
+					result[lint.ObjectKey(named.Obj())] = true
+				}
+			}
+		}
+	}
+	return result
+}
+
+func sortedDefs(pkg *lint.Package) []*ast.Ident {
+	result := make([]*ast.Ident, 0, len(pkg.TypesInfo.Defs))
+	for id := range pkg.TypesInfo.Defs {
+		result = append(result, id)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		pi, pj := pkg.Fset().Position(result[i].Pos()), pkg.Fset().Position(result[j].Pos())
+		if pi.Filename != pj.Filename {
+			return pi.Filename < pj.Filename
+		}
+		return pi.Offset < pj.Offset
+	})
+	return result
+}
+
+// unexportedName returns the name with its leading upper case letters turned
+// to lower case: Foo becomes foo, HTTPServer becomes httpServer and ID becomes id.
+func unexportedName(name string) string {
+	runes := []rune(name)
+	n := 0
+	for n < len(runes) && unicode.IsUpper(runes[n]) {
+		n++
+	}
+	if n > 1 && n < len(runes) && unicode.IsLower(runes[n]) {
+		n-- // keep the first letter of the next word
+	}
+	for i := 0; i < n; i++ {
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// renameFix returns the fix renaming obj, and all the references to it, to
+// newName, or nil if the new name would conflict with another declaration.
+func renameFix(module *lint.Module, obj types.Object, newName string) *lint.SuggestedFix {
+	if token.Lookup(newName).IsKeyword() || newName == obj.Name() {
+		return nil
+	}
+
+	refs := append(append([]lint.Ref{}, module.DefsOf(obj)...), module.UsesOf(obj)...)
+	if len(refs) == 0 || hasRenameConflict(obj, newName, refs) {
+		return nil
+	}
+
+	fix := &lint.SuggestedFix{Message: fmt.Sprintf("rename %s to %s", obj.Name(), newName)}
+	seen := map[token.Position]bool{}
+	for _, ref := range refs {
+		start := ref.Pkg.Fset().Position(ref.Ident.Pos())
+		if seen[start] {
+			continue // file shared by several package variants
+		}
+		seen[start] = true
+		fix.Edits = append(fix.Edits, lint.Edit{
+			Start:   start,
+			End:     ref.Pkg.Fset().Position(ref.Ident.End()),
+			NewText: newName,
+		})
+	}
+	return fix
+}
+
+// hasRenameConflict returns true if renaming obj to newName would make the
+// name to conflict with, or to be shadowed by, another declaration.
+func hasRenameConflict(obj types.Object, newName string, refs []lint.Ref) bool {
+	for _, ref := range refs {
+		pkg := ref.Pkg.TypesPkg
+		var owner types.Type
+		switch o := ref.Pkg.TypesInfo.ObjectOf(ref.Ident).(type) {
+		case *types.Func:
+			if recv := o.Type().(*types.Signature).Recv(); recv != nil {
+				owner = recv.Type()
+			}
+		case *types.Var:
+			if o.IsField() {
+				owner = fieldOwner(o, pkg)
+				if owner == nil {
+					return true
+				}
+			}
+		}
+
+		if owner != nil {
+			if other, _, _ := types.LookupFieldOrMethod(owner, true, pkg, newName); other != nil {
+				return true
+			}
+			continue
+		}
+
+		if types.Universe.Lookup(newName) != nil || pkg.Scope().Lookup(newName) != nil {
+			return true
+		}
+		for i := 0; i < pkg.Scope().NumChildren(); i++ {
+			if pkg.Scope().Child(i).Lookup(newName) != nil {
+				return true // an import of the same name
+			}
+		}
+		if scope := pkg.Scope().Innermost(ref.Ident.Pos()); scope != nil {
+			if _, other := scope.LookupParent(newName, ref.Ident.Pos()); other != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ApplyToPackage applies the rule to given package.
+func (r *UnnecessaryExportRule) ApplyToPackage(pkg *lint.Package, arguments lint.Arguments, failures chan lint.Failure) {
+}
+
+// ApplyToFile applies the rule to given file.
+func (r *UnnecessaryExportRule) ApplyToFile(file *lint.File, arguments lint.Arguments) []lint.Failure {
+	return nil
+}
+
+// Name returns the rule name.
+func (r *UnnecessaryExportRule) Name() string {
+	return "unnecessary-export"
+}
@@ -3,111 +3,305 @@ package rule
 import (
 	"fmt"
 	"go/ast"
-	"sync"
+	"go/types"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/chavacava/gusano/lint"
 )
 
-type toIgnoreType map[*lint.Package]map[*ast.Ident]bool
+// UnusedSymbolRule lints unused symbols of packages.
+// A symbol is unused if it is not reachable from the roots of the package:
+// exported symbols, main and init functions, cgo exported functions,
+// go:linkname targets and the configured entry points.
+// Declarations used from outside of the type system (go:generate arguments,
+// template functions, plugin symbols...) or marked with a //gusano:keep
+// comment are roots too.
+// Methods are reachable if they are called, if they are exported methods of
+// types exposed by the package API, or if their type is converted to an
+// interface and they implement an interface in scope.
+// Symbols of non-test files that are reachable only from test files are
+// reported as used only by tests.
+type UnusedSymbolRule struct{}
 
-// UnusedSymbolRule lints unused params in functions.
-type UnusedSymbolRule struct {
-	sync.Mutex
-	toIgnore toIgnoreType
+// unusedSymbolOptions are the options of the rule, given as a table argument:
+//
+//	[rule.unused-symbol]
+//	arguments = [{ entryPoints = ["run", "example.com/mod/pkg.T.method"], fieldTags = ["json"], keep = ["\\.Legacy\\w*$"] }]
+type unusedSymbolOptions struct {
+	// entryPoints are the names, optionally qualified by the package path,
+	// of the declarations to be considered as roots.
+	entryPoints map[string]bool
+	// keep are the regular expressions matching the qualified names of the
+	// declarations to be considered as roots.
+	keep []*regexp.Regexp
+	// fieldTags are the struct tag keys that make fields to be considered as read.
+	fieldTags []string
+	// fieldReaders are the qualified names of the functions that read all
+	// the fields of their arguments.
+	fieldReaders map[string]bool
+	// testsSeverity is the severity of the symbols used only by tests;
+	// if empty, the severity of the rule applies.
+	testsSeverity lint.Severity
+	// ignoreExportTest makes the symbols declared in export_test.go-style
+	// files, that expose internals to external tests, not to be reported.
+	ignoreExportTest bool
 }
 
-func (r *UnusedSymbolRule) createToIgnore(pkg *lint.Package) {
-	if r.toIgnore == nil {
-		r.toIgnore = toIgnoreType{}
+// exportTestRE matches the names of the files exposing internals to external tests.
+var exportTestRE = regexp.MustCompile(`^export(_\w+)?_test\.go$`)
+
+// defaultFieldTags are the struct tag keys of the most common encoders.
+var defaultFieldTags = []string{
+	"json", "yaml", "xml", "toml", "db", "bson", "msgpack", "mapstructure",
+	"protobuf", "gorm", "sql", "form", "query", "env", "csv",
+}
+
+// defaultFieldReaders are the functions reading all the fields of their
+// arguments through reflection or by relying on their memory layout.
+var defaultFieldReaders = []string{
+	"encoding/binary.Read", "encoding/binary.Write", "encoding/binary.Size",
+	"encoding/gob.Encoder.Encode", "encoding/gob.Decoder.Decode",
+	"encoding/json.Marshal", "encoding/json.MarshalIndent", "encoding/json.Unmarshal",
+	"encoding/json.Encoder.Encode", "encoding/json.Decoder.Decode",
+	"encoding/xml.Marshal", "encoding/xml.MarshalIndent", "encoding/xml.Unmarshal",
+	"encoding/xml.Encoder.Encode", "encoding/xml.Decoder.Decode",
+	"reflect.DeepEqual", "reflect.TypeOf", "reflect.ValueOf",
+	"unsafe.Alignof", "unsafe.Offsetof", "unsafe.Sizeof",
+}
+
+func (r *UnusedSymbolRule) parseOptions(arguments lint.Arguments) (unusedSymbolOptions, error) {
+	options := unusedSymbolOptions{
+		entryPoints:  map[string]bool{},
+		fieldTags:    defaultFieldTags,
+		fieldReaders: map[string]bool{},
 	}
-	if r.toIgnore[pkg] == nil {
-		r.toIgnore[pkg] = map[*ast.Ident]bool{}
+	fieldReaders := defaultFieldReaders
+	err := parseTables(arguments, r.Name(), func(option string, value interface{}) error {
+		var err error
+		switch option {
+		case "entryPoints":
+			var entryPoints []string
+			entryPoints, err = toStrings(value)
+			for _, ep := range entryPoints {
+				options.entryPoints[ep] = true
+			}
+		case "keep":
+			var exprs []string
+			exprs, err = toStrings(value)
+			for _, expr := range exprs {
+				re, err := regexp.Compile(expr)
+				if err != nil {
+					return fmt.Errorf("invalid regular expression %q: %v", expr, err)
+				}
+				options.keep = append(options.keep, re)
+			}
+		case "fieldTags":
+			options.fieldTags, err = toStrings(value)
+		case "fieldReaders":
+			fieldReaders, err = toStrings(value)
+		case "testsSeverity":
+			severity, ok := value.(string)
+			if !ok || severity != lint.SeverityWarning && severity != lint.SeverityError {
+				return fmt.Errorf("expected %q or %q", lint.SeverityWarning, lint.SeverityError)
+			}
+			options.testsSeverity = lint.Severity(severity)
+		case "ignoreExportTest":
+			options.ignoreExportTest, err = toBool(value)
+		default:
+			return errUnknownOption
+		}
+		return err
+	})
+	for _, fr := range fieldReaders {
+		options.fieldReaders[fr] = true
 	}
-
-	return
+	return options, err
 }
 
-func (r *UnusedSymbolRule) ignore(pkg *lint.Package, id *ast.Ident) {
-	r.Lock()
-	defer r.Unlock()
+// CheckArguments checks the arguments of the rule.
+func (r *UnusedSymbolRule) CheckArguments(arguments lint.Arguments) error {
+	_, err := r.parseOptions(arguments)
+	return err
+}
 
-	r.createToIgnore(pkg)
-	_, ok := r.toIgnore[pkg][id]
-	if ok {
-		panic("symbol defined twice " + id.Name)
+func (o unusedSymbolOptions) isEntryPoint(obj types.Object) bool {
+	if len(o.entryPoints) == 0 {
+		return false
 	}
+	qn := qualifiedName(obj)
+	return o.entryPoints[qn] || o.entryPoints[strings.TrimPrefix(qn, obj.Pkg().Path()+".")]
+}
 
-	r.toIgnore[pkg][id] = true
+func (o unusedSymbolOptions) isKept(obj types.Object) bool {
+	if len(o.keep) == 0 {
+		return false
+	}
+	qn := qualifiedName(obj)
+	for _, re := range o.keep {
+		if re.MatchString(qn) {
+			return true
+		}
+	}
+	return false
 }
 
 // ApplyToPackage applies the rule to given package.
 func (r *UnusedSymbolRule) ApplyToPackage(pkg *lint.Package, arguments lint.Arguments, failures chan lint.Failure) {
-	r.Lock()
-	defer r.Unlock()
-
 	if pkg.TypesInfo == nil {
 		return
 	}
+	options, _ := r.parseOptions(arguments) // checked by CheckArguments
+
+	// identifiers that are not subject to the rule (params, receivers, embedded types...)
+	toIgnore := map[*ast.Ident]bool{}
+	for _, file := range pkg.Files() {
+		ast.Walk(symbolScanner{toIgnore}, file.AST)
+	}
+
+	graph := newRefGraph(pkg, options)
+	reachable := graph.reachable()
+	production := graph.reachableFromProduction()
+	reported := r.reportUnconvertedTypes(pkg, graph, reachable, failures)
 
 	for id, d := range pkg.TypesInfo.Defs {
 		isInitFunc := id.String() == "init" // TODO provide more precise init func identification
 		isMainFunc := id.String() == "main" && pkg.IsMain()
-		mustIgnore := d == nil || isInitFunc || isMainFunc || id.IsExported() || id.String() == "_" || r.toIgnore[pkg][id]
+		mustIgnore := d == nil || isInitFunc || isMainFunc || id.String() == "_" || toIgnore[id] || reported[d]
 		if mustIgnore {
 			continue
 		}
 
-		found := false
-		for _, u := range pkg.TypesInfo.Uses {
-			if u == d {
-				found = true
-				break
-			}
+		position := pkg.Fset().Position(id.Pos())
+		if options.ignoreExportTest && exportTestRE.MatchString(filepath.Base(position.Filename)) {
+			continue
 		}
 
-		if !found {
-			kind := "method"
+		_, isNode := graph.nodes[d]
+		usedByTestsOnly := isNode && reachable[d] && !production[d] && !graph.inTests(d)
+		if isNode && reachable[d] && !usedByTestsOnly || !isNode && len(pkg.UsesOf(d)) > 0 {
+			continue
+		}
 
-			if id.Obj != nil {
-				kind = r.retrieveIdKind(id.Obj.Decl, id.Obj.Kind.String())
-			}
+		kind := objectKind(d)
 
-			//			fmt.Printf("unused %v (%+v)\n", id, id.Obj)
+		if usedByTestsOnly {
 			failures <- lint.Failure{
+				RuleName:   r.Name(),
+				Category:   "tests",
 				Confidence: 1,
-				Failure:    fmt.Sprintf("unused %v %v", kind, d.Name()),
+				Failure:    fmt.Sprintf("%v %v is used only by tests", kind, d.Name()),
 				Node:       id,
-				Position:   lint.FailurePosition{Start: pkg.Fset().Position(id.Pos())},
+				Position:   lint.FailurePosition{Start: position},
+				Severity:   options.testsSeverity,
 			}
+			continue
+		}
+
+		msg := fmt.Sprintf("unused %v %v", kind, d.Name())
+		if isField(d) && graph.writtenFrom(d, reachable) {
+			msg = fmt.Sprintf("field %v is written but never read", d.Name())
+		} else if isNode {
+			if chain := graph.deadChain(d); len(chain) > 1 {
+				msg += fmt.Sprintf(" (only used by dead code: %s)", chainNames(chain))
+			}
+		}
+
+		failures <- lint.Failure{
+			RuleName:   r.Name(),
+			Confidence: 1,
+			Failure:    msg,
+			Node:       id,
+			Position:   lint.FailurePosition{Start: position},
 		}
 	}
+}
+
+// reportUnconvertedTypes reports the reachable types having methods that are
+// only needed to implement interfaces while the types are never converted to
+// interfaces. It returns these methods.
+func (r *UnusedSymbolRule) reportUnconvertedTypes(pkg *lint.Package, graph *refGraph, reachable map[types.Object]bool, failures chan lint.Failure) map[types.Object]bool {
+	result := map[types.Object]bool{}
+	scope := pkg.TypesPkg.Scope()
+	for _, name := range scope.Names() {
+		tn, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || !reachable[tn] || types.IsInterface(tn.Type()) || graph.convertedFrom(tn, reachable) {
+			continue
+		}
 
-	delete(r.toIgnore, pkg)
+		var methods []string
+		ifaces := map[string]bool{}
+		for _, t := range []types.Type{tn.Type(), types.NewPointer(tn.Type())} {
+			for m, implemented := range graph.interfaceMethods(t) {
+				if reachable[m] || result[m] {
+					continue
+				}
+				result[m] = true
+				methods = append(methods, m.Name())
+				for _, iface := range implemented {
+					ifaces[interfaceName(iface, pkg.TypesPkg)] = true
+				}
+			}
+		}
+		if len(methods) == 0 {
+			continue
+		}
+
+		sort.Strings(methods)
+		ifaceNames := []string{}
+		for name := range ifaces {
+			ifaceNames = append(ifaceNames, name)
+		}
+		sort.Strings(ifaceNames)
+
+		unused := "method " + methods[0] + " is unused"
+		if len(methods) > 1 {
+			unused = "methods " + strings.Join(methods, ", ") + " are unused"
+		}
+		id := graph.nodes[tn]
+		failures <- lint.Failure{
+			RuleName:   r.Name(),
+			Confidence: 0.8,
+			Failure:    fmt.Sprintf("type %s implements %s but is never converted to an interface: %s", tn.Name(), strings.Join(ifaceNames, ", "), unused),
+			Node:       id,
+			Position:   lint.FailurePosition{Start: pkg.Fset().Position(tn.Pos())},
+		}
+	}
+	return result
 }
-func (r *UnusedSymbolRule) retrieveIdKind(t interface{}, defaultValue string) string {
-	if defaultValue == "" {
-		defaultValue = "method"
+
+func interfaceName(tn *types.TypeName, from *types.Package) string {
+	if tn.Pkg() == nil || tn.Pkg() == from {
+		return tn.Name()
 	}
-	switch t.(type) {
-	case *ast.Field:
-		return "field"
-	case *ast.ValueSpec:
-		// can not determine if it is a const or a var
-		return defaultValue
-	case *ast.FuncDecl:
+	return tn.Pkg().Name() + "." + tn.Name()
+}
+
+// objectKind returns the kind of declaration of obj, as named in failures.
+func objectKind(obj types.Object) string {
+	switch o := obj.(type) {
+	case *types.Const:
+		return "const"
+	case *types.Var:
+		if o.IsField() {
+			return "field"
+		}
+		return "var"
+	case *types.Func:
+		if o.Type().(*types.Signature).Recv() != nil {
+			return "method"
+		}
 		return "function"
-	case *ast.TypeSpec:
+	case *types.TypeName:
 		return "type"
-	default:
-		return defaultValue
 	}
+	return "symbol"
 }
 
 // ApplyToFile applies the rule to given file.
 func (r *UnusedSymbolRule) ApplyToFile(file *lint.File, arguments lint.Arguments) []lint.Failure {
-	ss := &symbolScanner{r, file.Pkg}
-	ast.Walk(ss, file.AST)
-
 	return nil
 }
 
@@ -116,13 +310,23 @@ func (r *UnusedSymbolRule) Name() string {
 	return "unused-symbol"
 }
 
+// RequiresConsensus returns true because a symbol is unused only if it is
+// unused under every build configuration.
+func (r *UnusedSymbolRule) RequiresConsensus() bool {
+	return true
+}
+
+// symbolScanner collects the identifiers that must not be reported.
 type symbolScanner struct {
-	r   *UnusedSymbolRule
-	pkg *lint.Package
+	toIgnore map[*ast.Ident]bool
 }
 
 func (w symbolScanner) Visit(node ast.Node) ast.Visitor {
 	switch v := node.(type) {
+	case *ast.TypeSpec:
+		if v.TypeParams != nil {
+			w.ignoreAllIdUnder(v.TypeParams)
+		}
 	case *ast.Field:
 		if len(v.Names) == 0 { // embedded type
 			w.ignoreAllIdUnder(v.Type)
@@ -134,6 +338,7 @@ func (w symbolScanner) Visit(node ast.Node) ast.Visitor {
 		return nil
 	case *ast.FuncLit:
 		w.ignoreFuncType(v.Type)
+		ast.Walk(w, v.Body)
 		return nil
 	case *ast.FuncType:
 		w.ignoreFuncType(v)
@@ -144,15 +349,22 @@ func (w symbolScanner) Visit(node ast.Node) ast.Visitor {
 		}
 
 		w.ignoreFuncType(v.Type)
+		if v.Body != nil {
+			ast.Walk(w, v.Body)
+		}
 		return nil
 	}
 
 	return w
 }
 
-func (s symbolScanner) ignoreAllIdUnder(node ast.Node) {
-	ignorer := &ignorer{&s}
-	ast.Walk(ignorer, node)
+func (w symbolScanner) ignoreAllIdUnder(node ast.Node) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok {
+			w.toIgnore[id] = true
+		}
+		return true
+	})
 }
 
 func (w symbolScanner) ignoreFuncType(ft *ast.FuncType) {
@@ -160,6 +372,9 @@ func (w symbolScanner) ignoreFuncType(ft *ast.FuncType) {
 		return
 	}
 
+	if ft.TypeParams != nil {
+		w.ignoreAllIdUnder(ft.TypeParams)
+	}
 	if ft.Params != nil {
 		w.ignoreAllIdUnder(ft.Params)
 	}
@@ -167,17 +382,3 @@ func (w symbolScanner) ignoreFuncType(ft *ast.FuncType) {
 		w.ignoreAllIdUnder(ft.Results)
 	}
 }
-
-type ignorer struct {
-	ss *symbolScanner
-}
-
-func (w ignorer) Visit(node ast.Node) ast.Visitor {
-	switch v := node.(type) {
-	case *ast.Ident:
-		//		fmt.Printf("ignoring %v %+v\n", v, v.Obj)
-		w.ss.r.ignore(w.ss.pkg, v)
-	}
-
-	return w
-}
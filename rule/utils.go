@@ -1,8 +1,15 @@
 package rule
 
 import (
+	"errors"
+	"fmt"
 	"go/ast"
+	"go/types"
 	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/chavacava/gusano/lint"
 )
 
 //const styleGuideBase = "https://golang.org/wiki/CodeReviewComments"
@@ -42,7 +49,6 @@ var knownNameExceptions = map[string]bool{
 	"kWh":          true,
 }
 
-/*
 func isCgoExported(f *ast.FuncDecl) bool {
 	if f.Recv != nil || f.Doc == nil {
 		return false
@@ -56,7 +62,93 @@ func isCgoExported(f *ast.FuncDecl) bool {
 	}
 	return false
 }
-*/
+
+// matchesPackage returns true if the package path matches the pattern: a
+// package path, optionally ending with "/..." to match the packages under a
+// path, or "..." to match all packages.
+func matchesPackage(pattern, path string) bool {
+	if pattern == path || pattern == "..." {
+		return true
+	}
+	prefix := strings.TrimSuffix(pattern, "/...")
+	return prefix != pattern && (path == prefix || strings.HasPrefix(path, prefix+"/"))
+}
+
+// errUnknownOption is returned by the option parsers of parseTable for the
+// options that the rule does not define.
+var errUnknownOption = errors.New("unknown option")
+
+// parseTables calls parseTable for every argument of a rule.
+func parseTables(arguments lint.Arguments, ruleName string, parse func(option string, value interface{}) error) error {
+	for _, arg := range arguments {
+		if err := parseTable(arg, ruleName, parse); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseTable calls parse for every option of a rule argument, in name order.
+// The argument must be a table; the errors returned by parse are reported as
+// invalid values of the option.
+func parseTable(arg interface{}, ruleName string, parse func(option string, value interface{}) error) error {
+	table, ok := arg.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("invalid argument %v for %s rule, expected a table", arg, ruleName)
+	}
+	options := make([]string, 0, len(table))
+	for option := range table {
+		options = append(options, option)
+	}
+	sort.Strings(options)
+	for _, option := range options {
+		value := table[option]
+		err := parse(option, value)
+		switch {
+		case err == errUnknownOption:
+			return fmt.Errorf("unknown option %s for %s rule", option, ruleName)
+		case err != nil:
+			return fmt.Errorf("invalid value %v for option %s of %s rule: %v", value, option, ruleName, err)
+		}
+	}
+	return nil
+}
+
+// toStrings converts a rule option value to a list of strings.
+func toStrings(value interface{}) ([]string, error) {
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, errors.New("expected a list of strings")
+	}
+	result := make([]string, len(list))
+	for i, v := range list {
+		s, ok := v.(string)
+		if !ok {
+			return nil, errors.New("expected a list of strings")
+		}
+		result[i] = s
+	}
+	return result, nil
+}
+
+// toBool converts a rule option value to a boolean.
+func toBool(value interface{}) (bool, error) {
+	b, ok := value.(bool)
+	if !ok {
+		return false, errors.New("expected a boolean")
+	}
+	return b, nil
+}
+
+// toInt converts a rule option value to an integer not lower than min.
+func toInt(value interface{}, min int64) (int, error) {
+	n, ok := value.(int64)
+	if !ok || n < min {
+		return 0, fmt.Errorf("expected an integer greater than or equal to %d", min)
+	}
+	return int(n), nil
+}
+
 var allCapsRE = regexp.MustCompile(`^[A-Z0-9_]+$`)
 
 /*
@@ -193,3 +285,57 @@ func gofmt(x ast.Expr) string {
 	return buf.String()
 }
 */
+
+// receiverTypeName returns the name of the receiver type of the method.
+func receiverTypeName(fd *ast.FuncDecl) string {
+	if fd.Recv == nil || len(fd.Recv.List) == 0 {
+		return ""
+	}
+	t := fd.Recv.List[0].Type
+	for {
+		switch e := t.(type) {
+		case *ast.StarExpr:
+			t = e.X
+		case *ast.IndexExpr:
+			t = e.X
+		case *ast.IndexListExpr:
+			t = e.X
+		case *ast.ParenExpr:
+			t = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// memberName returns the name of the object qualified, for methods, by the
+// name of their receiver type (e.g. "T.method").
+func memberName(obj types.Object) string {
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return obj.Name()
+	}
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return obj.Name()
+	}
+	t := recv.Type()
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	if named, ok := t.(*types.Named); ok {
+		return named.Obj().Name() + "." + obj.Name()
+	}
+	return obj.Name()
+}
+
+// qualifiedName returns the name of the object qualified by its package path
+// and, for methods, by its receiver type (e.g. "example.com/p.T.method").
+func qualifiedName(obj types.Object) string {
+	if obj.Pkg() == nil {
+		return memberName(obj)
+	}
+	return obj.Pkg().Path() + "." + memberName(obj)
+}
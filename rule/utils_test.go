@@ -0,0 +1,58 @@
+package rule
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chavacava/gusano/lint"
+)
+
+func TestCheckArguments(t *testing.T) {
+	table := func(option string, value interface{}) lint.Arguments {
+		return lint.Arguments{map[string]interface{}{option: value}}
+	}
+	list := func(s ...interface{}) []interface{} { return s }
+	tests := []struct {
+		rule      lint.ConfigurableRule
+		arguments lint.Arguments
+		want      string
+	}{
+		{&UnusedSymbolRule{}, nil, ""},
+		{&UnusedSymbolRule{}, table("keep", list("^main\\.")), ""},
+		{&UnusedSymbolRule{}, lint.Arguments{"keep"}, "invalid argument keep for unused-symbol rule, expected a table"},
+		{&UnusedSymbolRule{}, table("keep", list("(")), "invalid value [(] for option keep of unused-symbol rule: invalid regular expression \"(\": error parsing regexp: missing closing ): `(`"},
+		{&UnusedSymbolRule{}, table("entryPoints", list("run", "example.com/mod/pkg.T.method")), ""},
+		{&UnusedSymbolRule{}, lint.Arguments{"entryPoints"}, "invalid argument entryPoints for unused-symbol rule, expected a table"},
+		{&UnusedSymbolRule{}, table("entryPoints", "main"), "invalid value main for option entryPoints of unused-symbol rule: expected a list of strings"},
+		{&UnusedSymbolRule{}, table("fieldTags", list("json", 1)), "invalid value [json 1] for option fieldTags of unused-symbol rule: expected a list of strings"},
+		{&UnusedSymbolRule{}, table("testsSeverity", "info"), "invalid value info for option testsSeverity of unused-symbol rule: expected \"warning\" or \"error\""},
+		{&UnusedSymbolRule{}, table("ignoreExportTest", "yes"), "invalid value yes for option ignoreExportTest of unused-symbol rule: expected a boolean"},
+		{&UnusedSymbolRule{}, table("entrypoints", list()), "unknown option entrypoints for unused-symbol rule"},
+		{&UnnecessaryExportRule{}, table("public", "api/..."), "invalid value api/... for option public of unnecessary-export rule: expected a list of strings"},
+		{&SimplifiableSignatureRule{}, table("minCallSites", int64(0)), "invalid value 0 for option minCallSites of simplifiable-signature rule: expected an integer greater than or equal to 1"},
+		{&SimplifiableSignatureRule{}, table("module", int64(1)), "invalid value 1 for option module of simplifiable-signature rule: expected a boolean"},
+		{&LayeringRule{}, table("layers", list("app")), "invalid value [app] for option layers of layering rule: expected a table of package patterns by layer"},
+		{&LayeringRule{}, table("layers", map[string]interface{}{"a": list("m/..."), "b": list("m/...")}), "invalid value map[a:[m/...] b:[m/...]] for option layers of layering rule: pattern \"m/...\" belongs to layers "},
+		{&LayeringRule{}, table("allow", map[string]interface{}{"a": list("b")}), "invalid option allow of layering rule: unknown layer a"},
+		{&PackageMetricsRule{}, table("maxInstability", 1.5), "invalid value 1.5 for option maxInstability of package-metrics rule: expected a number between 0 and 1"},
+		{&PackageMetricsRule{}, table("maxLCOM", int64(-1)), "invalid value -1 for option maxLCOM of package-metrics rule: expected an integer greater than or equal to 0"},
+		{&ImportCyclesRule{}, table("dirDepth", "2"), "invalid value 2 for option dirDepth of import-cycles rule: expected an integer greater than or equal to 1"},
+		{&ForbiddenAPIRule{}, table("message", "do not use"), "invalid argument map[message:do not use] for forbidden-api rule, expected either a package or a symbol"},
+		{&ForbiddenAPIRule{}, table("symbol", ""), "invalid value  for option symbol of forbidden-api rule: expected a non-empty string"},
+		{&DuplicateCodeRule{}, table("minTokens", 10.5), "invalid value 10.5 for option minTokens of duplicate-code rule: expected an integer greater than or equal to 1"},
+		{&BudgetsRule{}, table("maxLines", int64(10)), "unknown option maxLines for budgets rule"},
+		{&UnhandledErrorRule{}, table("ignore", "fmt.Println"), "invalid value fmt.Println for option ignore of unhandled-error rule: expected a list of strings"},
+		{&DeepExitRule{}, table("panics", "no"), "invalid value no for option panics of deep-exit rule: expected a boolean"},
+	}
+	for _, test := range tests {
+		err := test.rule.CheckArguments(test.arguments)
+		switch {
+		case test.want == "" && err != nil:
+			t.Errorf("%s.CheckArguments(%v): unexpected error %v", test.rule.Name(), test.arguments, err)
+		case test.want != "" && err == nil:
+			t.Errorf("%s.CheckArguments(%v): expected error %q", test.rule.Name(), test.arguments, test.want)
+		case err != nil && !strings.HasPrefix(err.Error(), test.want):
+			t.Errorf("%s.CheckArguments(%v) = %q, want %q", test.rule.Name(), test.arguments, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/chavacava/gusano/lint"
+	"github.com/chavacava/gusano/rule"
+)
+
+func TestBudgets(t *testing.T) {
+	args := map[string]interface{}{
+		"maxFiles": int64(1), "maxFileLines": int64(30), "maxExported": int64(2), "maxMethods": int64(3),
+		"maxCyclomatic": int64(5), "maxCognitive": int64(10), "maxParams": int64(5), "maxResults": int64(3),
+	}
+	config := lint.Config{Rules: lint.RulesConfig{"budgets": {Arguments: lint.Arguments{args}}}}
+	testRule(t, &rule.BudgetsRule{}, config, "pkg19")
+}
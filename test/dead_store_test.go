@@ -0,0 +1,12 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/chavacava/gusano/lint"
+	"github.com/chavacava/gusano/rule"
+)
+
+func TestDeadStore(t *testing.T) {
+	testRule(t, &rule.DeadStoreRule{}, lint.Config{}, "pkg8")
+}
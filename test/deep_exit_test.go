@@ -0,0 +1,13 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/chavacava/gusano/lint"
+	"github.com/chavacava/gusano/rule"
+)
+
+func TestDeepExit(t *testing.T) {
+	config := lint.Config{Tests: true, Rules: lint.RulesConfig{"deep-exit": {}}}
+	testRule(t, &rule.DeepExitRule{}, config, "pkg21/...")
+}
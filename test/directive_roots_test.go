@@ -0,0 +1,13 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/chavacava/gusano/lint"
+	"github.com/chavacava/gusano/rule"
+)
+
+func TestUnusedSymbolDirectiveRoots(t *testing.T) {
+	config := lint.Config{Rules: lint.RulesConfig{"unused-symbol": {Arguments: lint.Arguments{map[string]interface{}{"keep": []interface{}{`\.legacy\w*$`}}}}}}
+	testRule(t, &rule.UnusedSymbolRule{}, config, "pkg12")
+}
@@ -0,0 +1,20 @@
+package test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/chavacava/gusano/lint"
+	"github.com/chavacava/gusano/lint/linttest"
+	"github.com/chavacava/gusano/rule"
+)
+
+func TestDuplicateCode(t *testing.T) {
+	failures := linttest.RunWithConfig(t, "../testdata", &rule.DuplicateCodeRule{}, lint.Config{}, "./pkg18/...")
+	if len(failures) != 1 || len(failures[0].Related) != 1 {
+		t.Fatalf("expected one clone group with a related copy, got %+v", failures)
+	}
+	if related := failures[0].Related[0].Start; filepath.Base(related.Filename) != "b.go" || related.Line != 10 {
+		t.Errorf("related copy at %v, want b.go:10", related)
+	}
+}
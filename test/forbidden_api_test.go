@@ -0,0 +1,20 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/chavacava/gusano/lint"
+	"github.com/chavacava/gusano/rule"
+)
+
+func TestForbiddenAPI(t *testing.T) {
+	const base = "github.com/chavacava/gusano/testdata/pkg17/"
+	args := lint.Arguments{
+		map[string]interface{}{"package": "crypto/md5", "message": "MD5 is broken"},
+		map[string]interface{}{"symbol": "time.Now", "message": "time must be mockable", "replacement": base + "clock.Now", "except": []interface{}{base + "clock"}},
+		map[string]interface{}{"symbol": "(*sync.Mutex).Lock", "message": "handlers must not block", "only": []interface{}{base + "handlers/..."}},
+		map[string]interface{}{"symbol": base + "model.User.Password", "message": "secrets must not be read"},
+	}
+	config := lint.Config{Rules: lint.RulesConfig{"forbidden-api": {Arguments: args}}}
+	testRuleWithFixes(t, &rule.ForbiddenAPIRule{}, config, "pkg17/...")
+}
@@ -0,0 +1,12 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/chavacava/gusano/lint"
+	"github.com/chavacava/gusano/rule"
+)
+
+func TestUnusedSymbolGenerics(t *testing.T) {
+	testRule(t, &rule.UnusedSymbolRule{}, lint.Config{}, "pkg13")
+}
@@ -0,0 +1,14 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/chavacava/gusano/lint"
+	"github.com/chavacava/gusano/rule"
+)
+
+func TestImportCycles(t *testing.T) {
+	args := map[string]interface{}{"dirDepth": int64(3)}
+	config := lint.Config{Tests: true, Rules: lint.RulesConfig{"import-cycles": {Arguments: lint.Arguments{args}}}}
+	testRule(t, &rule.ImportCyclesRule{}, config, "pkg16/...")
+}
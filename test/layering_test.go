@@ -0,0 +1,27 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/chavacava/gusano/lint"
+	"github.com/chavacava/gusano/rule"
+)
+
+func TestLayering(t *testing.T) {
+	const base = "github.com/chavacava/gusano/testdata/pkg14/"
+	args := map[string]interface{}{
+		"layers": map[string]interface{}{
+			"domain": []interface{}{base + "domain/..."},
+			"app":    []interface{}{base + "app/..."},
+			"infra":  []interface{}{base + "infra/..."},
+			"cmd":    []interface{}{base + "cmd/..."},
+		},
+		"allow": map[string]interface{}{
+			"app":   []interface{}{"domain"},
+			"infra": []interface{}{"domain"},
+			"cmd":   []interface{}{"app", "infra", "domain"},
+		},
+	}
+	config := lint.Config{Rules: lint.RulesConfig{"layering": {Arguments: lint.Arguments{args}}}}
+	testRule(t, &rule.LayeringRule{}, config, "pkg14/...")
+}
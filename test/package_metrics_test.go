@@ -0,0 +1,14 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/chavacava/gusano/lint"
+	"github.com/chavacava/gusano/rule"
+)
+
+func TestPackageMetrics(t *testing.T) {
+	args := map[string]interface{}{"maxAfferent": int64(1), "maxEfferent": int64(1), "maxLCOM": int64(1), "maxInstability": 0.9, "maxDistance": 0.4}
+	config := lint.Config{Rules: lint.RulesConfig{"package-metrics": {Arguments: lint.Arguments{args}}}}
+	testRule(t, &rule.PackageMetricsRule{}, config, "pkg15/...")
+}
@@ -0,0 +1,12 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/chavacava/gusano/lint"
+	"github.com/chavacava/gusano/rule"
+)
+
+func TestSimplifiableSignature(t *testing.T) {
+	testRule(t, &rule.SimplifiableSignatureRule{}, lint.Config{}, "pkg11")
+}
@@ -0,0 +1,14 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/chavacava/gusano/lint"
+	"github.com/chavacava/gusano/rule"
+)
+
+func TestUnhandledError(t *testing.T) {
+	args := map[string]interface{}{"ignore": []interface{}{"fmt.Fprintln"}}
+	config := lint.Config{Rules: lint.RulesConfig{"unhandled-error": {Arguments: lint.Arguments{args}}}}
+	testRule(t, &rule.UnhandledErrorRule{}, config, "pkg20")
+}
@@ -0,0 +1,13 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/chavacava/gusano/lint"
+	"github.com/chavacava/gusano/rule"
+)
+
+func TestUnnecessaryExport(t *testing.T) {
+	config := lint.Config{Rules: lint.RulesConfig{"unnecessary-export": {Arguments: lint.Arguments{map[string]interface{}{"public": []interface{}{"github.com/chavacava/gusano/testdata/pkg10/api/..."}}}}}}
+	testRuleWithFixes(t, &rule.UnnecessaryExportRule{}, config, "pkg10/...")
+}
@@ -0,0 +1,40 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/chavacava/gusano/lint"
+	"github.com/chavacava/gusano/rule"
+)
+
+func TestUnusedSymbol(t *testing.T) {
+	testRule(t, &rule.UnusedSymbolRule{}, lint.Config{}, "pkg1", "pkg2", "pkg3")
+}
+
+// TestUnusedSymbolVariants lints pkg4 with tests under linux and windows:
+// onlyLinux is unused only in the windows variant, and must not be reported.
+func TestUnusedSymbolVariants(t *testing.T) {
+	config := lint.Config{Tests: true, Builds: []lint.BuildConfig{{GOOS: "linux"}, {GOOS: "windows"}}}
+	testRule(t, &rule.UnusedSymbolRule{}, config, "pkg4")
+}
+
+func TestUnusedSymbolDeadCode(t *testing.T) {
+	config := lint.Config{Rules: lint.RulesConfig{"unused-symbol": {Arguments: lint.Arguments{map[string]interface{}{"entryPoints": []interface{}{"configuredEntryPoint"}}}}}}
+	testRule(t, &rule.UnusedSymbolRule{}, config, "pkg5")
+}
+
+func TestUnusedSymbolInterfaces(t *testing.T) {
+	testRule(t, &rule.UnusedSymbolRule{}, lint.Config{}, "pkg6")
+}
+
+func TestUnusedSymbolFields(t *testing.T) {
+	testRule(t, &rule.UnusedSymbolRule{}, lint.Config{}, "pkg7")
+}
+
+func TestUnusedSymbolTestsOnly(t *testing.T) {
+	config := lint.Config{
+		Tests: true,
+		Rules: lint.RulesConfig{"unused-symbol": {Arguments: lint.Arguments{map[string]interface{}{"ignoreExportTest": true, "testsSeverity": "warning"}}}},
+	}
+	testRule(t, &rule.UnusedSymbolRule{}, config, "pkg9")
+}
@@ -10,197 +10,29 @@ import (
 	"testing"
 
 	"github.com/chavacava/gusano/lint"
+	"github.com/chavacava/gusano/lint/linttest"
 )
 
-/*
-func testRule(t *testing.T, filename string, rule lint.Rule, config ...*lint.RuleConfig) {
-	baseDir := "../testdata/"
-	filename = filename + ".go"
-	src, err := ioutil.ReadFile(baseDir + filename)
-	if err != nil {
-		t.Fatalf("Bad filename path in test for %s: %v", rule.Name(), err)
-	}
-	stat, err := os.Stat(baseDir + filename)
-	if err != nil {
-		t.Fatalf("Cannot get file info for %s: %v", rule.Name(), err)
-	}
-	c := map[string]lint.RuleConfig{}
-	if config != nil {
-		c[rule.Name()] = *config[0]
+// testRule lints the given testdata packages with the rule and checks the
+// failures against the MATCH and want annotations of their files.
+func testRule(t *testing.T, rule lint.Rule, config lint.Config, pkgs ...string) {
+	patterns := make([]string, len(pkgs))
+	for i, pkg := range pkgs {
+		patterns[i] = "./" + pkg
 	}
-	if parseInstructions(t, filename, src) == nil {
-		assertSuccess(t, baseDir, stat, []lint.Rule{rule}, c)
-		return
-	}
-	assertFailures(t, baseDir, stat, src, []lint.Rule{rule}, c)
-}
-*/
-/*
-func assertSuccess(t *testing.T, baseDir string, fi os.FileInfo, rules []lint.Rule, config map[string]lint.RuleConfig) error {
-	/*
-		l := lint.New(func(file string) ([]byte, error) {
-			return ioutil.ReadFile(baseDir + file)
-		})
-
-		ps, err := l.Lint([][]string{[]string{fi.Name()}}, rules, lint.Config{
-			Rules: config,
-		})
-		if err != nil {
-			return err
-		}
-
-		failures := ""
-		for p := range ps {
-			failures += p.Failure
-		}
-		if failures != "" {
-			t.Errorf("Expected the rule to pass but got the following failures: %s", failures)
-		}
-
-	return nil
-}
-*/
-//func assertFailures(t *testing.T, baseDir string, fi os.FileInfo, src []byte, rules []lint.Rule, config map[string]lint.RuleConfig) error {
-/*
-		l := lint.New(func(file string) ([]byte, error) {
-			return ioutil.ReadFile(baseDir + file)
-		})
-
-		ins := parseInstructions(t, fi.Name(), src)
-		if ins == nil {
-			return errors.Errorf("Test file %v does not have instructions", fi.Name())
-		}
-
-		ps, err := l.Lint([][]string{[]string{fi.Name()}}, rules, lint.Config{
-			Rules: config,
-		})
-		if err != nil {
-			return err
-		}
-
-		failures := []lint.Failure{}
-		for f := range ps {
-			failures = append(failures, f)
-		}
-
-		for _, in := range ins {
-			ok := false
-			for i, p := range failures {
-				if p.Position.Start.Line != in.Line {
-					continue
-				}
-				if in.Match == p.Failure {
-					// check replacement if we are expecting one
-					if in.Replacement != "" {
-						// ignore any inline comments, since that would be recursive
-						r := p.ReplacementLine
-						if i := strings.Index(r, " //"); i >= 0 {
-							r = r[:i]
-						}
-						if r != in.Replacement {
-							t.Errorf("Lint failed at %s:%d; got replacement %q, want %q", fi.Name(), in.Line, r, in.Replacement)
-						}
-					}
-
-					// remove this problem from ps
-					copy(failures[i:], failures[i+1:])
-					failures = failures[:len(failures)-1]
-
-					// t.Logf("/%v/ matched at %s:%d", in.Match, fi.Name(), in.Line)
-					ok = true
-					break
-				}
-			}
-			if !ok {
-				t.Errorf("Lint failed at %s:%d; /%v/ did not match", fi.Name(), in.Line, in.Match)
-			}
-		}
-		for _, p := range failures {
-			t.Errorf("Unexpected problem at %s:%d: %v", fi.Name(), p.Position.Start.Line, p.Failure)
-		}
-
-	return nil
-}
-*/
-type instruction struct {
-	Line        int    // the line number this applies to
-	Match       string // what pattern to match
-	Replacement string // what the suggested replacement line should be
+	linttest.RunWithConfig(t, "../testdata", rule, config, patterns...)
 }
 
-// parseInstructions parses instructions from the comments in a Go source file.
-// It returns nil if none were parsed.
-/*func parseInstructions(t *testing.T, filename string, src []byte) []instruction {
-	fset := token.NewFileSet()
-	f, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
-	if err != nil {
-		t.Fatalf("Test file %v does not parse: %v", filename, err)
-	}
-	var ins []instruction
-	for _, cg := range f.Comments {
-		ln := fset.Position(cg.Pos()).Line
-		raw := cg.Text()
-		for _, line := range strings.Split(raw, "\n") {
-			if line == "" || strings.HasPrefix(line, "#") {
-				continue
-			}
-			if line == "OK" && ins == nil {
-				// so our return value will be non-nil
-				ins = make([]instruction, 0)
-				continue
-			}
-			if strings.Contains(line, "MATCH") {
-				match, err := extractPattern(line)
-				if err != nil {
-					t.Fatalf("At %v:%d: %v", filename, ln, err)
-				}
-				matchLine := ln
-				if i := strings.Index(line, "MATCH:"); i >= 0 {
-					// This is a match for a different line.
-					lns := strings.TrimPrefix(line[i:], "MATCH:")
-					lns = lns[:strings.Index(lns, " ")]
-					matchLine, err = strconv.Atoi(lns)
-					if err != nil {
-						t.Fatalf("Bad match line number %q at %v:%d: %v", lns, filename, ln, err)
-					}
-				}
-				var repl string
-				if r, ok := extractReplacement(line); ok {
-					repl = r
-				}
-				ins = append(ins, instruction{
-					Line:        matchLine,
-					Match:       match,
-					Replacement: repl,
-				})
-			}
-		}
-	}
-	return ins
-}
-*/
-/*
-func extractPattern(line string) (string, error) {
-	a, b := strings.Index(line, "/"), strings.LastIndex(line, "/")
-	if a == -1 || a == b {
-		return "", fmt.Errorf("malformed match instruction %q", line)
+// testRuleWithFixes is like testRule and also checks the suggested fixes of
+// the failures against the .golden files of the testdata packages.
+func testRuleWithFixes(t *testing.T, rule lint.Rule, config lint.Config, pkgs ...string) {
+	patterns := make([]string, len(pkgs))
+	for i, pkg := range pkgs {
+		patterns[i] = "./" + pkg
 	}
-	return line[a+1 : b], nil
+	linttest.RunWithSuggestedFixes(t, "../testdata", rule, config, patterns...)
 }
-*/
-/*
-func extractReplacement(line string) (string, bool) {
-	// Look for this:  / -> `
-	// (the end of a match and start of a backtick string),
-	// and then the closing backtick.
-	const start = "/ -> `"
-	a, b := strings.Index(line, start), strings.LastIndex(line, "`")
-	if a < 0 || a > b {
-		return "", false
-	}
-	return line[a+len(start) : b], true
-}
-*/
+
 /*
 func render(fset *token.FileSet, x interface{}) string {
 	var buf bytes.Buffer
@@ -1,7 +1,7 @@
 package pkg1
 
-type mi int
-type str struct {
+type mi int       // MATCH /unused type mi \(only used by dead code: str -> mi\)/
+type str struct { // MATCH /unused type str/
 	mi
-	a int
+	a int // want "unused field a"
 }
@@ -0,0 +1,10 @@
+// Package api is a public package: its exported identifiers are not reported.
+package api
+
+import "github.com/chavacava/gusano/testdata/pkg10/api/internal/conv"
+
+// Version is not used outside api but api is public.
+const Version = "1.0"
+
+// Describe is used by app.
+func Describe() string { return Version + conv.Itoa(1) }
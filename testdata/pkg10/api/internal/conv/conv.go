@@ -0,0 +1,12 @@
+// Package conv is internal to api: it is checked even if api is public.
+package conv
+
+import "strconv"
+
+// Itoa is used by api.
+func Itoa(i int) string { return Pad(strconv.Itoa(i)) }
+
+// Pad is only used in conv.
+func Pad(s string) string { // MATCH /exported function Pad is not used outside its package/
+	return " " + s
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/chavacava/gusano/testdata/pkg10/api"
+	"github.com/chavacava/gusano/testdata/pkg10/lib"
+)
+
+// Run is exported in a main package.
+func Run() { // MATCH /exported function Run is not used outside its package/
+	fmt.Println(lib.New(lib.Options{Size: lib.Limit}), lib.Value(1), api.Describe(), lib.Dial("localhost"), server{}.Handle())
+}
+
+type server struct{}
+
+func (server) Handle() string { return "handled" }
+
+func main() {
+	Run()
+}
@@ -0,0 +1,73 @@
+// Package lib is used by the app package.
+package lib
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Limit is used by app.
+const Limit = 10
+
+// Default is only used in lib.
+const Default = 3 // MATCH /exported const Default is not used outside its package, it could be unexported/
+
+// Options are used by app, and so is its API.
+type Options struct {
+	Size    int
+	Verbose bool
+}
+
+// Validate is only used in lib.
+func (o Options) Validate() bool { return o.Size > 0 } // MATCH /exported method Validate is not used outside its package/
+
+// Config is only used in lib.
+type Config struct { // MATCH /exported type Config is not used outside its package/
+	Name string // MATCH /exported field Name is not used outside its package/
+}
+
+// Payload is only used in lib, its fields are encoded.
+type Payload struct { // MATCH /exported type Payload is not used outside its package/
+	ID string `json:"id"`
+}
+
+// Client is exposed by Dial, that app uses, even if app does not name it.
+type Client struct {
+	Addr string
+}
+
+// Dial is used by app.
+func Dial(addr string) *Client { return &Client{Addr: addr} }
+
+// Handler is implemented by a type of app, even if app does not name it.
+type Handler interface {
+	Handle() string
+}
+
+// Value is used by app.
+type Value int
+
+// String is needed by fmt.Stringer.
+func (v Value) String() string { return fmt.Sprint(int(v)) }
+
+// New is used by app.
+func New(o Options) string {
+	if !o.Validate() {
+		return ""
+	}
+	c := Config{Name: NormalizeURL(fmt.Sprint(o.Size))}
+	if o.Verbose {
+		c.Name += "!"
+	}
+	p := Payload{ID: c.Name}
+	return p.ID + fmt.Sprint(Default) + Error()
+}
+
+// NormalizeURL is only used in lib.
+func NormalizeURL(s string) string { return strings.TrimSpace(s) } // MATCH /exported function NormalizeURL is not used outside its package/
+
+// Error can not be renamed: error is a predeclared identifier.
+func Error() string { return "" } // MATCH /exported function Error is not used outside its package/
+
+// Strings can not be renamed: strings is imported.
+func Strings() []string { return nil } // MATCH /exported function Strings is not used outside its package/
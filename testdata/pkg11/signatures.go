@@ -0,0 +1,105 @@
+package pkg11
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+func save(name string, verbose bool) error { // MATCH /result 1 \(error\) of function save is never used by its callers/
+	if verbose {
+		fmt.Println("saving", name)
+	}
+	if name == "" {
+		return errors.New("empty name")
+	}
+	return nil
+}
+
+func load(path string, mode int) (data []byte, err error) { // MATCH /result err \(error\) of function load is never used by its callers/
+	data, err = os.ReadFile(path)
+	if mode > 0 {
+		fmt.Println(len(data))
+	}
+	return data, err
+}
+
+func scale(x, factor int) int { // MATCH /parameter factor of function scale always receives 10/
+	return x * factor
+}
+
+func label(prefix string, sep string) string { // MATCH /parameter sep of function label always receives ":"/
+	return prefix + sep
+}
+
+func lookup(key string, fallback []string) []string { // MATCH /parameter fallback of function lookup always receives nil/
+	if key == "" {
+		return fallback
+	}
+	return []string{key}
+}
+
+// used as a value: its signature is constrained
+func handler(code int) error {
+	return fmt.Errorf("code %d", code)
+}
+
+// called once: a constant parameter is not reported
+func once(n int) int {
+	return n + 1
+}
+
+func variadic(format string, args ...interface{}) string {
+	return fmt.Sprintf(format, args...)
+}
+
+func pair() (int, error) {
+	return 1, nil
+}
+
+func consume(n int, err error) {
+	fmt.Println(n, err)
+}
+
+type store struct {
+	items map[string]int
+}
+
+func (s *store) put(key string, value int) bool { // MATCH /result 1 \(bool\) of method put is never used by its callers/
+	_, existed := s.items[key]
+	s.items[key] = value
+	return !existed
+}
+
+// String implements fmt.Stringer
+func (s *store) String() string {
+	return fmt.Sprint(len(s.items))
+}
+
+func run() {
+	save("a", false)
+	_ = save("b", true)
+	go save("c", false)
+
+	d, _ := load("x", 0)
+	var e, _ = load("y", 1)
+	fmt.Println(d, e)
+
+	fmt.Println(scale(1, 10), scale(2, 10))
+	fmt.Println(label("a", ":"), label("b", ":"))
+	fmt.Println(lookup("a", nil), lookup("", nil))
+
+	f := handler
+	fmt.Println(f(1), handler(1), handler(1))
+
+	fmt.Println(once(1))
+	fmt.Println(variadic("%d", 1), variadic("%x", 2))
+
+	consume(pair())
+	consume(pair())
+
+	s := &store{items: map[string]int{}}
+	s.put("a", 1)
+	(*store).put(s, "b", 2)
+	fmt.Println(s.String(), s.String())
+}
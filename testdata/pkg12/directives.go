@@ -0,0 +1,72 @@
+package pkg12
+
+import (
+	"strings"
+	"text/template"
+)
+
+//go:generate stringer -type=color -output=color_string.go
+type color int
+
+const (
+	red color = iota
+	green
+)
+
+//go:generate mockgen -source=store.go -destination=mock.go
+func source() {} // MATCH /unused function source/
+
+func mock() {} // MATCH /unused function mock/
+
+//go:generate go run gen.go -func generated
+func run() {} // MATCH /unused function run/
+
+func generated() {}
+
+//gusano:keep
+var palette = []color{red, green}
+
+//gusano:keep
+func loadedByName() string {
+	return helperOfKept()
+}
+
+func helperOfKept() string { return "kept" }
+
+var registry = map[string]int{} //gusano:keep used by the debugger
+
+type options struct {
+	verbose bool //gusano:keep read through unsafe
+	level   int  // MATCH /field level is written but never read/
+}
+
+func legacyHandler() {}
+
+func legacyHook() {}
+
+func unused() {} // MATCH /unused function unused/
+
+type view struct{}
+
+func (view) title(s string) string { return strings.Title(s) }
+
+func (view) footer() string { return "" } // MATCH /unused method footer/
+
+func upper(s string) string { return strings.ToUpper(s) }
+
+func lower(s string) string { return strings.ToLower(s) }
+
+var helpers = template.FuncMap{ // MATCH /unused var helpers/
+	"lower": lower,
+}
+
+// Render renders the template with the helpers.
+func Render(text string) (*template.Template, error) {
+	var v view
+	o := options{verbose: true, level: 1}
+	_ = o
+	return template.New("t").Funcs(template.FuncMap{
+		"upper": upper,
+		"title": v.title,
+	}).Parse(text)
+}
@@ -0,0 +1,58 @@
+package pkg13
+
+import "fmt"
+
+type list[T any] struct {
+	head *node[T]
+	size int
+}
+
+type node[T any] struct {
+	value T        // MATCH /field value is written but never read/
+	next  *node[T] // MATCH /field next is written but never read/
+}
+
+func (l *list[T]) push(v T) {
+	l.head = &node[T]{value: v, next: l.head}
+	l.size++
+}
+
+func (l *list[T]) len() int { return l.size }
+
+func (l *list[T]) unusedMethod() {} // MATCH /unused method unusedMethod/
+
+func mapSlice[S ~[]E, E any, R any](s S, f func(E) R) []R {
+	r := make([]R, 0, len(s))
+	for _, e := range s {
+		r = append(r, f(e))
+	}
+	return r
+}
+
+type number interface{ ~int | ~float64 }
+
+func sum[N number](ns ...N) N {
+	var t N
+	for _, n := range ns {
+		t += n
+	}
+	return t
+}
+
+const answer = 42
+
+var unusedVar = 1 // MATCH /unused var unusedVar/
+
+const unusedConst = 2 // MATCH /unused const unusedConst/
+
+func Run() {
+	var l list[int]
+	l.push(1)
+	fmt.Println(l.len(), mapSlice([]int{1}, func(i int) string { return fmt.Sprint(i) }), sum(1, 2), answer)
+}
+
+func ignoresParam[T any](n int) int { return n }
+
+type box[T any] struct{ n int }
+
+func UseBox() int { return ignoresParam[string](box[int]{n: 1}.n) }
@@ -0,0 +1,14 @@
+package app
+
+import (
+	"github.com/chavacava/gusano/testdata/pkg14/app/events"
+	"github.com/chavacava/gusano/testdata/pkg14/domain"
+	"github.com/chavacava/gusano/testdata/pkg14/infra/db" // want "package github.com/chavacava/gusano/testdata/pkg14/app of layer app must not import github.com/chavacava/gusano/testdata/pkg14/infra/db of layer infra \\(it can only import domain\\)"
+)
+
+// PlaceOrder creates an order and saves it.
+func PlaceOrder(id int) (domain.Order, string) {
+	o := domain.Order{ID: id}
+	db.Save(o)
+	return o, events.Created
+}
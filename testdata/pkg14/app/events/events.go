@@ -0,0 +1,4 @@
+package events
+
+// Created is the name of the event emitted when an order is created.
+const Created = "created"
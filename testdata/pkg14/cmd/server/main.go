@@ -0,0 +1,14 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/chavacava/gusano/testdata/pkg14/app"
+	"github.com/chavacava/gusano/testdata/pkg14/infra/db"
+)
+
+func main() {
+	o, event := app.PlaceOrder(1)
+	db.Save(o)
+	fmt.Println(event)
+}
@@ -0,0 +1,17 @@
+package domain
+
+import (
+	"fmt"
+
+	"github.com/chavacava/gusano/testdata/pkg14/app/events" // want "package github.com/chavacava/gusano/testdata/pkg14/domain of layer domain must not import github.com/chavacava/gusano/testdata/pkg14/app/events of layer app \\(it can not import other layers\\)"
+)
+
+// Order is an order of the domain.
+type Order struct {
+	ID int
+}
+
+// String describes the order.
+func (o Order) String() string {
+	return fmt.Sprintf("order %d (%s)", o.ID, events.Created)
+}
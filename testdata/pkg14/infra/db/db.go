@@ -0,0 +1,12 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/chavacava/gusano/testdata/pkg14/domain"
+)
+
+// Save saves the order.
+func Save(o domain.Order) {
+	fmt.Println("saving", o)
+}
@@ -0,0 +1,14 @@
+package api // want "package github.com/chavacava/gusano/testdata/pkg15/api imports 2 packages \\(max 1\\)" "package github.com/chavacava/gusano/testdata/pkg15/api has an instability of 1.00 \\(max 0.90\\)"
+
+import (
+	"strings"
+
+	"github.com/chavacava/gusano/testdata/pkg15/core"
+	"github.com/chavacava/gusano/testdata/pkg15/store"
+)
+
+// Lookup looks up the key in a new store.
+func Lookup(key string) (core.Item, bool) {
+	var r core.Reader = &store.Store{}
+	return r.Get(strings.ToLower(key))
+}
@@ -0,0 +1,17 @@
+package core // want "package github.com/chavacava/gusano/testdata/pkg15/core is imported by 2 packages \\(max 1\\)"
+
+// Item is an item of the store.
+type Item struct {
+	Key   string
+	Value string
+}
+
+// Reader reads items.
+type Reader interface {
+	Get(key string) (Item, bool)
+}
+
+// Writer writes items.
+type Writer interface {
+	Put(item Item)
+}
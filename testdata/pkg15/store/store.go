@@ -0,0 +1,49 @@
+package store // want "package github.com/chavacava/gusano/testdata/pkg15/store is at 0.50 from the main sequence \\(max 0.40, abstractness 0.00, instability 0.50\\)"
+
+import "github.com/chavacava/gusano/testdata/pkg15/core"
+
+// Store keeps items in memory and counts the accesses.
+type Store struct { // want "the 4 methods of type Store form 2 unrelated groups \\(max LCOM 1\\), consider splitting it"
+	items map[string]core.Item
+	hits  int
+	miss  int
+}
+
+// Get returns the item with the given key.
+func (s *Store) Get(key string) (core.Item, bool) {
+	item, ok := s.items[key]
+	return item, ok
+}
+
+// Put stores the item.
+func (s *Store) Put(item core.Item) {
+	s.items[item.Key] = item
+}
+
+// Hit counts a hit.
+func (s *Store) Hit() {
+	s.hits++
+}
+
+// Ratio returns the ratio of hits.
+func (s *Store) Ratio() float64 {
+	return float64(s.hits) / float64(s.hits+s.miss)
+}
+
+// Counter is a cohesive type.
+type Counter struct {
+	n int
+}
+
+// Inc increments the counter.
+func (c *Counter) Inc() { c.n++ }
+
+// Reset resets the counter.
+func (c *Counter) Reset() { c.n = 0 }
+
+// Add increments the counter n times.
+func (c *Counter) Add(n int) {
+	for i := 0; i < n; i++ {
+		c.Inc()
+	}
+}
@@ -0,0 +1,4 @@
+package config
+
+// Level is the log level.
+var Level = 1
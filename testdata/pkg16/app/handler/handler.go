@@ -0,0 +1,8 @@
+package handler
+
+import "github.com/chavacava/gusano/testdata/pkg16/util/format"
+
+// Handle handles a message.
+func Handle(msg string) string {
+	return format.Pretty(msg) + format.Short(msg)
+}
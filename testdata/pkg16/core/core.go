@@ -0,0 +1,4 @@
+package core
+
+// Version returns the version of the core.
+func Version() string { return "1.0" }
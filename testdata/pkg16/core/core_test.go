@@ -0,0 +1,14 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/chavacava/gusano/testdata/pkg16/core"
+	"github.com/chavacava/gusano/testdata/pkg16/store" // want "the tests of package github.com/chavacava/gusano/testdata/pkg16/core could not be moved into it: github.com/chavacava/gusano/testdata/pkg16/store imports it back through github.com/chavacava/gusano/testdata/pkg16/store -> github.com/chavacava/gusano/testdata/pkg16/core, for core.Version"
+)
+
+func TestVersion(t *testing.T) {
+	if store.Describe() != "store "+core.Version() {
+		t.Fail()
+	}
+}
@@ -0,0 +1,6 @@
+package store
+
+import "github.com/chavacava/gusano/testdata/pkg16/core"
+
+// Describe describes the store.
+func Describe() string { return "store " + core.Version() }
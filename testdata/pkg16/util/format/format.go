@@ -0,0 +1,9 @@
+package format
+
+import "strings"
+
+// Pretty formats a message.
+func Pretty(msg string) string { return strings.TrimSpace(msg) }
+
+// Short shortens a message.
+func Short(msg string) string { return msg[:1] }
@@ -0,0 +1,14 @@
+package log
+
+import (
+	"fmt"
+
+	"github.com/chavacava/gusano/testdata/pkg16/app/config" // want "directories github.com/chavacava/gusano/testdata/pkg16/app, github.com/chavacava/gusano/testdata/pkg16/util import each other \\(github.com/chavacava/gusano/testdata/pkg16/app/handler -> github.com/chavacava/gusano/testdata/pkg16/util/format, github.com/chavacava/gusano/testdata/pkg16/util/log -> github.com/chavacava/gusano/testdata/pkg16/app/config\\): moving config.Level out of github.com/chavacava/gusano/testdata/pkg16/app/config would remove the import of github.com/chavacava/gusano/testdata/pkg16/util/log"
+)
+
+// Print prints the message if the level allows it.
+func Print(msg string) {
+	if config.Level > 0 {
+		fmt.Println(msg)
+	}
+}
@@ -0,0 +1,6 @@
+package clock
+
+import "time"
+
+// Now returns the current time.
+func Now() time.Time { return time.Now() }
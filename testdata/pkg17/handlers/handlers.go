@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"crypto/md5" // want "use of forbidden package crypto/md5: MD5 is broken"
+	"sync"
+	"time"
+
+	"github.com/chavacava/gusano/testdata/pkg17/model"
+)
+
+var mu sync.Mutex
+
+// Handle handles a request of the user.
+func Handle(u model.User) (time.Time, [16]byte) {
+	mu.Lock()   // want "use of forbidden method sync.Mutex.Lock: handlers must not block"
+	lock := mu.Lock // want "use of forbidden method sync.Mutex.Lock: handlers must not block"
+	_ = lock
+	defer mu.Unlock()
+	sum := md5.Sum([]byte(u.Password)) // want "use of forbidden field github.com/chavacava/gusano/testdata/pkg17/model.User.Password: secrets must not be read"
+	return time.Now(), sum // want "use of forbidden function time.Now: time must be mockable \\(use github.com/chavacava/gusano/testdata/pkg17/clock.Now instead\\)"
+}
@@ -0,0 +1,10 @@
+package jobs
+
+import "time"
+
+var started = time.Now() // want "use of forbidden function time.Now: time must be mockable \\(use github.com/chavacava/gusano/testdata/pkg17/clock.Now instead\\)"
+
+// Uptime returns the seconds since the start.
+func Uptime() float64 {
+	return time.Now().Sub(started).Seconds() // want "use of forbidden function time.Now: time must be mockable \\(use github.com/chavacava/gusano/testdata/pkg17/clock.Now instead\\)"
+}
@@ -0,0 +1,8 @@
+package jobs
+
+import "time"
+
+// Stamp returns the current time as a Unix timestamp.
+func Stamp() int64 {
+	return time.Now().Unix() // want "use of forbidden function time.Now: time must be mockable \\(use github.com/chavacava/gusano/testdata/pkg17/clock.Now instead\\)"
+}
@@ -0,0 +1,7 @@
+package model
+
+// User is a user.
+type User struct {
+	Name     string
+	Password string
+}
@@ -0,0 +1,30 @@
+package a
+
+import (
+	"sort"
+	"strings"
+)
+
+// Normalize normalizes the names.
+func Normalize(names []string) []string {
+	result := []string{} // want "duplicated code: 2 copies of \\d+ tokens, in a.Normalize, b.Clean"
+	seen := map[string]bool{}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		result = append(result, strings.ToLower(name))
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Short is too short to be reported.
+func Short(s string) string {
+	return strings.TrimSpace(s)
+}
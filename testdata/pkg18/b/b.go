@@ -0,0 +1,30 @@
+package b
+
+import (
+	"sort"
+	"strings"
+)
+
+// Clean cleans the tags, as a.Normalize does with other names.
+func Clean(tags []string) []string {
+	out := []string{}
+	seen := map[string]bool{}
+	for _, t := range tags {
+		t = strings.TrimSpace(t)
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		out = append(out, strings.ToUpper(t))
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Short is too short to be reported.
+func Short(s string) string {
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,40 @@
+package pkg19 // want "package .*/pkg19 exports 3 symbols \\(max 2\\)" "package .*/pkg19 has 2 files \\(max 1\\)" "file has 40 lines \\(max 30\\)"
+
+import "errors"
+
+// Limit is exported.
+const Limit = 10
+
+// Store is exported.
+type Store struct{ items map[string]int } // want "type Store has 4 methods \\(max 3\\)"
+
+func (s *Store) Get(key string) (int, bool) { v, ok := s.items[key]; return v, ok }
+func (s *Store) Put(key string, v int)      { s.items[key] = v }
+func (s *Store) Delete(key string)          { delete(s.items, key) }
+
+func (s Store) Len() int { return len(s.items) }
+
+// Classify has a cyclomatic complexity of 8 and a cognitive complexity of 14.
+func Classify(values []int, strict bool) (string, error) { // want "function Classify has a cyclomatic complexity of 8 \\(max 5\\)" "function Classify has a cognitive complexity of 14 \\(max 10\\)"
+	for _, v := range values { // +1
+		if v < 0 { // +2 (nesting 1)
+			if strict && v < -Limit { // +3 (nesting 2), +1 (&&)
+				return "", errors.New("negative")
+			}
+			continue
+		} else if v == 0 || v > Limit { // +1, +1 (||)
+			return "edge", nil
+		} else { // +1
+			func() {
+				if v == 1 { // +4 (nesting 3)
+					v++
+				}
+			}()
+		}
+	}
+	return "ok", nil
+}
+
+func configure(name string, a, b, c, d int, verbose bool) (int, int, int, error) { // want "function configure has 6 parameters \\(max 5\\)" "function configure has 4 results \\(max 3\\)"
+	return a, b, c + d, nil
+}
@@ -0,0 +1,3 @@
+package pkg19
+
+func helper() int { return Limit }
@@ -15,7 +15,7 @@ const reqResourcePos = 6
 const reqProtocolPos = 7
 const statusPos = 8
 const sizePos = 9
-const totalElements = 9
+const totalElements = 9 // MATCH /unused const totalElements/
 
 // if you have a problem and you use regexp to resolve it, now you have two problems
 const re = `^(\S+) (\S+) (\S+) \[([\w:/]+\s[+\-]\d{4})\] "(\S+)\s?(\S+)?\s?(\S+)?" (\d{3}|-) (\d+|-)\s?$`
@@ -0,0 +1,79 @@
+package pkg20
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+)
+
+var errInvalid = errors.New("invalid")
+
+func validate(n int) error {
+	if n < 0 {
+		return errInvalid
+	}
+	return nil
+}
+
+// register always succeeds, its error is reserved for future use.
+func register(name string) error {
+	if name == "" {
+		return nil
+	}
+	return noop()
+}
+
+func noop() error { return nil }
+
+// count returns a nil error on every path, through recursion.
+func count(n int) (int, error) {
+	if n == 0 {
+		return 0, nil
+	}
+	c, err := count(n - 1)
+	return c + 1, err
+}
+
+type store struct{}
+
+func (s *store) Save(data []byte) error { return os.WriteFile("data", data, 0o600) }
+
+func run(s *store, f *os.File, buf *bytes.Buffer) error {
+	validate(1)         // want "error returned by github.com/chavacava/gusano/testdata/pkg20.validate is not handled"
+	s.Save(nil)         // want "error returned by \\(\\*github.com/chavacava/gusano/testdata/pkg20.store\\).Save is not handled"
+	f.Close()           // want "error returned by \\(\\*os.File\\).Close is not handled"
+	(f.Sync)()          // want "error returned by \\(\\*os.File\\).Sync is not handled"
+	register("x")       // provably nil
+	count(3)            // provably nil
+	fmt.Println("ok")   // ignored by default
+	buf.WriteString("") // ignored by default
+	fmt.Fprintln(f)     // ignored by configuration
+	_ = validate(2)     // explicitly ignored
+
+	err := validate(3) // want "error returned by github.com/chavacava/gusano/testdata/pkg20.validate is assigned to err but never checked"
+	err = validate(4)
+	if err != nil {
+		return err
+	}
+	err = s.Save(nil) // want "error returned by \\(\\*github.com/chavacava/gusano/testdata/pkg20.store\\).Save is assigned to err but never checked"
+	err = register("y")
+	return err
+}
+
+func write(h hash.Hash64, sb *strings.Builder, buf *bytes.Buffer, w io.Writer) error {
+	h.Write([]byte("x"))     // never fails
+	fmt.Fprintf(sb, "%d", 1) // never fails
+	fmt.Fprint(buf, "x")     // never fails
+	fmt.Fprintf(w, "%d", 1)  // want "error returned by fmt.Fprintf is not handled"
+	w.Write(nil)             // want "error returned by \\(io.Writer\\).Write is not handled"
+
+	_, err := h.Write(nil)
+	_, err = fmt.Fprint(buf, "y")
+	_, err = fmt.Fprint(w, "z") // want "error returned by fmt.Fprint is assigned to err but never checked"
+	err = nil
+	return err
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"os"
+
+	"github.com/chavacava/gusano/testdata/pkg21/lib"
+)
+
+func main() {
+	lib.Load(os.Args[1])
+	run()
+}
+
+func run() {
+	if len(os.Args) > 2 {
+		os.Exit(2) // want "function main.run can stop the program: main.run -> os.Exit"
+	}
+}
+
+func init() {
+	os.Exit(0)
+}
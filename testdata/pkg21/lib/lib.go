@@ -0,0 +1,80 @@
+package lib
+
+import (
+	"errors"
+	"log"
+	"os"
+	"regexp"
+)
+
+// Load loads the configuration.
+func Load(path string) []byte {
+	data, err := read(path) // want "function lib.Load can stop the program: lib.Load -> lib.read -> lib.fail -> log.Fatal"
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+func read(path string) ([]byte, error) {
+	if path == "" {
+		fail(errors.New("empty path")) // want "function lib.read can stop the program: lib.read -> lib.fail -> log.Fatal"
+	}
+	return os.ReadFile(path)
+}
+
+func fail(err error) {
+	log.Fatal(err) // want "function lib.fail can stop the program: lib.fail -> log.Fatal"
+}
+
+// Parser parses expressions.
+type Parser struct{}
+
+// Parse parses the expression.
+func (p *Parser) Parse(expr string) int {
+	return p.parse(expr, 0) // want "function lib.Parser.Parse can panic: lib.Parser.Parse -> lib.Parser.parse -> panic"
+}
+
+func (p *Parser) parse(expr string, depth int) int {
+	if depth > 10 {
+		panic("too deep") // want "function lib.Parser.parse can panic: lib.Parser.parse -> panic"
+	}
+	if expr == "" {
+		return 0
+	}
+	return p.parse(expr[1:], depth+1) + 1
+}
+
+// SafeParse recovers the panics of the parser.
+func SafeParse(expr string) (n int, err error) {
+	defer catch(&err)
+	return new(Parser).Parse(expr), nil
+}
+
+func catch(err *error) {
+	if r := recover(); r != nil {
+		*err = errors.New("invalid expression")
+	}
+}
+
+// MustCompile panics if the expression is invalid, as documented.
+func MustCompile(expr string) *regexp.Regexp {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		panic(err)
+	}
+	return re
+}
+
+// Pattern calls a function documented to panic.
+func Pattern() *regexp.Regexp {
+	return MustCompile("a+")
+}
+
+// Mustache renders the template; despite its name it is not a MustX function.
+func Mustache(tmpl string) string {
+	if tmpl == "" {
+		panic("empty template") // want "function lib.Mustache can panic: lib.Mustache -> panic"
+	}
+	return tmpl
+}
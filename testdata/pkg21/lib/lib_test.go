@@ -0,0 +1,7 @@
+package lib
+
+import "testing"
+
+func TestLoad(t *testing.T) {
+	Load("")
+}
@@ -0,0 +1,10 @@
+// Package pkg4 is meant to be linted with tests and with linux and windows
+// build configurations: helper is used only by tests and onlyLinux only
+// under linux.
+package pkg4
+
+func helper() int { return 1 } // MATCH /function helper is used only by tests/
+
+func onlyLinux() int { return 2 }
+
+func unused() {} // MATCH /unused function unused/
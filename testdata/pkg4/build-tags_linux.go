@@ -0,0 +1,6 @@
+package pkg4
+
+var linuxValue = onlyLinux()
+
+// Value returns a value computed by linux helpers.
+func Value() int { return linuxValue }
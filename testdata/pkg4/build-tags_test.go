@@ -0,0 +1,9 @@
+package pkg4
+
+import "testing"
+
+func TestHelper(t *testing.T) {
+	if helper() != 1 {
+		t.Fail()
+	}
+}
@@ -0,0 +1,51 @@
+package pkg5
+
+import (
+	_ "unsafe" // required by go:linkname
+)
+
+// Live is a root, so are the functions it calls.
+func Live() int { return liveHelper() }
+
+func liveHelper() int { return 1 }
+
+func deadRoot() { // MATCH /^unused function deadRoot$/
+	deadHelper()
+	deadShared()
+}
+
+func deadHelper() { // MATCH /unused function deadHelper \(only used by dead code: deadRoot -> deadHelper\)/
+	deadLeaf()
+}
+
+func deadLeaf() {} // MATCH /unused function deadLeaf \(only used by dead code: deadRoot -> deadHelper -> deadLeaf\)/
+
+func deadShared() {} // MATCH /unused function deadShared \(only used by dead code: deadRoot -> deadShared\)/
+
+func ping() { pong() } // MATCH /unused function ping \(only used by dead code: pong -> ping\)/
+
+func pong() { ping() } // MATCH /unused function pong \(only used by dead code: ping -> pong\)/
+
+//export cgoEntry
+func cgoEntry() { usedByCgo() }
+
+func usedByCgo() {}
+
+//go:linkname linked runtime.nanotime
+func linked() int64
+
+var registered = register() // MATCH /unused var registered/
+
+func register() bool { return true }
+
+func configuredEntryPoint() { usedByEntryPoint() }
+
+func usedByEntryPoint() {}
+
+var _ = assertion()
+
+func assertion() bool { return true }
+
+func init() { usedByInit() }
+
+func usedByInit() {}
@@ -0,0 +1,68 @@
+package pkg6
+
+import (
+	"fmt"
+	"sort"
+)
+
+type shape interface {
+	area() float64
+}
+
+type square struct{ side float64 }
+
+func (s square) area() float64 { return s.side * s.side }
+
+type circle struct{ r float64 } // MATCH /type circle implements shape but is never converted to an interface: method area is unused/
+
+func (c circle) area() float64 { return 3 * c.r * c.r }
+
+// Total returns the total area of the shapes.
+func Total() float64 {
+	shapes := []shape{square{2}}
+	c := circle{1}
+	return shapes[0].area() + c.r
+}
+
+type byName []string
+
+func (b byName) Len() int           { return len(b) }
+func (b byName) Less(i, j int) bool { return b[i] < b[j] }
+func (b byName) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+
+type byLen []string // MATCH /type byLen implements sort.Interface but is never converted to an interface: methods Len, Less, Swap are unused/
+
+func (b byLen) Len() int           { return len(b) }
+func (b byLen) Less(i, j int) bool { return len(b[i]) < len(b[j]) }
+func (b byLen) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+
+// Sorted returns the names sorted.
+func Sorted(names []string) []string {
+	sort.Sort(byName(names))
+	lengths := byLen(names)
+	return lengths
+}
+
+type stringer struct{}
+
+func (stringer) String() string { return "stringer" }
+
+// Print prints a stringer.
+func Print() { fmt.Println(stringer{}) }
+
+type hidden struct{}
+
+func (hidden) Exported() {} // MATCH /unused method Exported/
+
+// Hidden returns the number of hidden values.
+func Hidden() int {
+	values := []hidden{{}}
+	return len(values)
+}
+
+type exposed struct{}
+
+func (exposed) Method() {}
+
+// New returns a value whose exported methods are part of the API.
+func New() *exposed { return &exposed{} }
@@ -0,0 +1,92 @@
+package pkg7
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"unsafe"
+)
+
+type record struct {
+	read    int
+	written int // MATCH /field written is written but never read/
+	counter int // MATCH /field counter is written but never read/
+	unused  int // MATCH /^unused field unused$/
+}
+
+// Use uses a record.
+func Use() int {
+	r := record{read: 1, written: 2}
+	r.written = 3
+	r.counter++
+	p := pair{1, 2}
+	return r.read + p.first
+}
+
+type pair struct {
+	first  int
+	second int // MATCH /field second is written but never read/
+}
+
+type dto struct {
+	Name  string `json:"name"`
+	count int    `db:"count"`
+	Stale string // MATCH /field Stale is written but never read/
+}
+
+// NewDTO builds a DTO.
+func NewDTO() int {
+	d := dto{Name: "n", count: 1, Stale: "s"}
+	return len(d.Name)
+}
+
+type header struct {
+	magic   uint32
+	version uint16
+}
+
+// WriteHeader writes a header.
+func WriteHeader(w io.Writer) error {
+	return binary.Write(w, binary.LittleEndian, header{1, 2})
+}
+
+type layout struct {
+	a byte
+	b int64
+}
+
+// Size returns the size of a layout.
+func Size() uintptr {
+	return unsafe.Sizeof(layout{})
+}
+
+type payload struct {
+	id    int
+	label string
+}
+
+// Encode encodes a payload.
+func Encode() ([]byte, error) {
+	p := payload{id: 1, label: "l"}
+	return json.Marshal(&p)
+}
+
+type view struct {
+	Title string
+	notes string // MATCH /field notes is written but never read/
+}
+
+// Render converts a view to an interface, its exported fields can be read through reflection.
+func Render() interface{} {
+	return view{Title: "t", notes: "n"}
+}
+
+type key struct {
+	kind string
+	id   int
+}
+
+// Lookup looks up a value indexed by a struct key.
+func Lookup(m map[key]int) int {
+	return m[key{"k", 1}]
+}
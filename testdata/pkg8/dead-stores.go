@@ -0,0 +1,121 @@
+package pkg8
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+var counter int // MATCH /package variable counter is written but never read/
+
+var hits int
+
+var Exported int
+
+var initialized = compute()
+
+func compute() int { return 42 }
+
+func count() {
+	counter = compute()
+	hits = 1
+	Exported = 2
+	fmt.Println(hits)
+}
+
+func overwritten() int {
+	x := compute() // MATCH /value assigned to x is never read/
+	x = 2
+	return x
+}
+
+func uncheckedError(s string) (int, error) {
+	n, err := strconv.Atoi(s) // MATCH /value assigned to err is never read/
+	m, err := strconv.Atoi(s + "0")
+	if err != nil {
+		return 0, err
+	}
+	return n + m, nil
+}
+
+func zeroValueInit(ok bool) string {
+	result := ""
+	if ok {
+		result = "yes"
+	} else {
+		result = "no"
+	}
+	return result
+}
+
+func loop(values []int) int {
+	sum := 0
+	for _, v := range values {
+		sum = sum + v
+	}
+	return sum
+}
+
+func lastAssignment(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	total = -1 // MATCH /value assigned to total is never read/
+	return len(values)
+}
+
+func parameter(p int) int {
+	p = compute() // MATCH /value assigned to p is never read/
+	return 0
+}
+
+func closure() int {
+	x := compute()
+	f := func() int { return x }
+	x = 3
+	return f()
+}
+
+func capturedWrite() int {
+	y := 0
+	func() { y = compute() }()
+	return y
+}
+
+func addressTaken() int {
+	z := compute()
+	p := &z
+	z = 5
+	return *p
+}
+
+func blankAssignment() {
+	v, err := strconv.Atoi("1")
+	if err != nil {
+		v = -1
+	}
+	_ = v
+}
+
+func namedResult() (err error) {
+	err = errors.New("overwritten")
+	err = nil
+	return
+}
+
+func deferredRead() (n int) {
+	defer func() { n++ }()
+	n = compute()
+	return n
+}
+
+func branches(ok bool) int {
+	a := compute() // MATCH /value assigned to a is never read/
+	if ok {
+		a = 1
+	} else {
+		a = 2
+	}
+	return a
+}
@@ -0,0 +1,12 @@
+package pkg9
+
+// CounterValue exposes newCounter to external tests.
+func CounterValue(values ...int) int {
+	c := newCounter()
+	for _, v := range values {
+		c.add(v)
+	}
+	return c.n
+}
+
+func (c *counter) Reset() { c.n = 0 }
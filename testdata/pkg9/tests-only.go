@@ -0,0 +1,31 @@
+// Package pkg9 is meant to be linted with tests and with the ignoreExportTest
+// option of unused-symbol.
+package pkg9
+
+type counter struct {
+	n int
+}
+
+func (c *counter) add(k int) { c.n += k }
+
+func newCounter() *counter { return &counter{} } // MATCH /function newCounter is used only by tests/
+
+// Sum returns the sum of the values.
+func Sum(values ...int) int {
+	c := &counter{}
+	for _, v := range values {
+		c.add(v)
+	}
+	return c.n
+}
+
+func square(x int) int { return x * x } // MATCH /function square is used only by tests/
+
+func cube(x int) int { return x * square(x) } // MATCH /function cube is used only by tests/
+
+func double(x int) int { return 2 * x }
+
+// Double returns the double of x.
+func Double(x int) int { return double(x) }
+
+var fixtures = []int{1, 2, 3} // MATCH /var fixtures is used only by tests/
@@ -0,0 +1,13 @@
+package pkg9
+
+import "testing"
+
+func TestPowers(t *testing.T) {
+	for _, x := range fixtures {
+		if cube(x) != x*x*x || double(x) != 2*x {
+			t.Fail()
+		}
+	}
+}
+
+func unusedTestHelper() {} // MATCH /unused function unusedTestHelper/
@@ -0,0 +1,63 @@
+package usage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Formats of the reports.
+var Formats = []string{"text", "json"}
+
+// Write writes the report to w in the given format.
+func (r *Report) Write(w io.Writer, format string) error {
+	switch format {
+	case "text":
+		b := &strings.Builder{}
+		for _, s := range r.Symbols {
+			s.writeText(b, r.Package)
+		}
+		_, err := io.WriteString(w, b.String())
+		return err
+	case "json":
+		return writeJSON(w, r)
+	}
+	return unknownFormat(format)
+}
+
+// Write writes the symbol and its references to w in the given format.
+func (s *Symbol) Write(w io.Writer, pkgPath, format string) error {
+	switch format {
+	case "text":
+		b := &strings.Builder{}
+		s.writeText(b, pkgPath)
+		_, err := io.WriteString(w, b.String())
+		return err
+	case "json":
+		return writeJSON(w, &Report{Package: pkgPath, Symbols: []*Symbol{s}})
+	}
+	return unknownFormat(format)
+}
+
+// writeText writes the symbol followed by its references, one per line.
+func (s *Symbol) writeText(b *strings.Builder, pkgPath string) {
+	plural := "s"
+	if len(s.Refs) == 1 {
+		plural = ""
+	}
+	fmt.Fprintf(b, "%s.%s (%s): %d reference%s\n", pkgPath, s.Name, s.Kind, len(s.Refs), plural)
+	for _, ref := range s.Refs {
+		fmt.Fprintf(b, "\t%v\t%s\n", ref, ref.Package)
+	}
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func unknownFormat(format string) error {
+	return fmt.Errorf("unknown report format %q, expected one of %s", format, strings.Join(Formats, ", "))
+}
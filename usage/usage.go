@@ -0,0 +1,212 @@
+// Package usage finds the references to the exported symbols of a package
+// from the loaded packages, to know every user of an API before changing it.
+package usage
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+	"strings"
+
+	"github.com/chavacava/gusano/lint"
+	"golang.org/x/tools/go/packages"
+)
+
+// Symbol is an exported symbol of a package and its references.
+type Symbol struct {
+	// Name is the name of the symbol, qualified by its type for methods and
+	// fields (e.g. Client.Close)
+	Name string `json:"name"`
+	// Kind is one of const, var, func, type, method and field
+	Kind string `json:"kind"`
+	Refs []Ref  `json:"refs"`
+
+	obj types.Object
+}
+
+// Ref is a reference to a symbol.
+type Ref struct {
+	// Package is the path of the referring package
+	Package string `json:"package"`
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+}
+
+func (r Ref) String() string {
+	return fmt.Sprintf("%s:%d:%d", r.File, r.Line, r.Column)
+}
+
+// Report lists the exported symbols of a package with their references.
+type Report struct {
+	Package string    `json:"package"`
+	Symbols []*Symbol `json:"symbols"`
+}
+
+// ParseSymbol splits a query of the form <package path>.<Symbol>, where the
+// symbol can be qualified by its type (e.g. example.com/mod/client.Client.Close).
+// As package paths can contain dots (e.g. gopkg.in/yaml.v3.Marshal), the
+// package path is the longest path, among the loaded packages and their
+// imports, followed by a dot in the query.
+func ParseSymbol(pkgs []*packages.Package, query string) (pkgPath, name string, err error) {
+	packages.Visit(pkgs, nil, func(p *packages.Package) {
+		if len(p.PkgPath) > len(pkgPath) && strings.HasPrefix(query, p.PkgPath+".") && len(query) > len(p.PkgPath)+1 {
+			pkgPath = p.PkgPath
+		}
+	})
+	if pkgPath == "" {
+		return "", "", fmt.Errorf("invalid symbol %q, expected <package path>.<Symbol> with a package among the loaded packages and their imports", query)
+	}
+	return pkgPath, query[len(pkgPath)+1:], nil
+}
+
+// APIUsage returns the exported symbols of the package with the references
+// to them from the other loaded packages, including its external tests.
+// The package must be loaded or imported by a loaded package.
+func APIUsage(pkgs []*packages.Package, pkgPath string) (*Report, error) {
+	report, err := newReport(pkgs, pkgPath)
+	if err != nil {
+		return nil, err
+	}
+	report.collect(pkgs, func(from string) bool { return from != pkgPath })
+	return report, nil
+}
+
+// WhoUses returns the symbol of the package with the given name, with all its
+// references from the loaded packages, including the package itself.
+func WhoUses(pkgs []*packages.Package, pkgPath, name string) (*Symbol, error) {
+	report, err := newReport(pkgs, pkgPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range report.Symbols {
+		if s.Name == name {
+			report.Symbols = []*Symbol{s}
+			report.collect(pkgs, func(string) bool { return true })
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot find the exported symbol %s in package %s", name, pkgPath)
+}
+
+// Unused returns the symbols of the report without references.
+func (r *Report) Unused() []*Symbol {
+	result := []*Symbol{}
+	for _, s := range r.Symbols {
+		if len(s.Refs) == 0 {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// newReport returns the report of the exported symbols of the package,
+// without references.
+func newReport(pkgs []*packages.Package, pkgPath string) (*Report, error) {
+	var target *types.Package
+	packages.Visit(pkgs, nil, func(p *packages.Package) {
+		if target == nil && p.PkgPath == pkgPath && p.Types != nil {
+			target = p.Types
+		}
+	})
+	if target == nil {
+		return nil, fmt.Errorf("cannot find package %s among the loaded packages and their imports", pkgPath)
+	}
+
+	report := &Report{Package: pkgPath}
+	add := func(name, kind string, obj types.Object) {
+		report.Symbols = append(report.Symbols, &Symbol{Name: name, Kind: kind, Refs: []Ref{}, obj: obj})
+	}
+	scope := target.Scope()
+	for _, name := range scope.Names() {
+		obj := scope.Lookup(name)
+		if !obj.Exported() {
+			continue
+		}
+		switch obj := obj.(type) {
+		case *types.Const:
+			add(name, "const", obj)
+		case *types.Var:
+			add(name, "var", obj)
+		case *types.Func:
+			add(name, "func", obj)
+		case *types.TypeName:
+			add(name, "type", obj)
+			addMembers(obj, add)
+		}
+	}
+	sort.Slice(report.Symbols, func(i, j int) bool { return report.Symbols[i].Name < report.Symbols[j].Name })
+	return report, nil
+}
+
+// addMembers adds the exported methods and fields of the type.
+func addMembers(tn *types.TypeName, add func(name, kind string, obj types.Object)) {
+	if named, ok := tn.Type().(*types.Named); ok && !tn.IsAlias() {
+		for i := 0; i < named.NumMethods(); i++ {
+			if m := named.Method(i); m.Exported() {
+				add(tn.Name()+"."+m.Name(), "method", m)
+			}
+		}
+	}
+	switch u := tn.Type().Underlying().(type) {
+	case *types.Struct:
+		for i := 0; i < u.NumFields(); i++ {
+			if f := u.Field(i); f.Exported() {
+				add(tn.Name()+"."+f.Name(), "field", f)
+			}
+		}
+	case *types.Interface:
+		for i := 0; i < u.NumExplicitMethods(); i++ {
+			if m := u.ExplicitMethod(i); m.Exported() {
+				add(tn.Name()+"."+m.Name(), "method", m)
+			}
+		}
+	}
+}
+
+// collect adds to the symbols of the report the references from the loaded
+// packages accepted by from. Packages are type-checked separately, so symbols
+// are matched by their object path.
+func (r *Report) collect(pkgs []*packages.Package, from func(pkgPath string) bool) {
+	byKey := map[string]*Symbol{}
+	for _, s := range r.Symbols {
+		if key := lint.ObjectKey(s.obj); key != "" {
+			byKey[key] = s
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, p := range pkgs {
+		if p.TypesInfo == nil || !from(p.PkgPath) {
+			continue
+		}
+		for id, obj := range p.TypesInfo.Uses {
+			if obj.Pkg() == nil || obj.Pkg().Path() != r.Package {
+				continue
+			}
+			s, ok := byKey[lint.ObjectKey(lint.Origin(obj))]
+			if !ok {
+				continue
+			}
+			position := p.Fset.Position(id.Pos())
+			if seen[position.String()] {
+				continue // file shared by several package variants
+			}
+			seen[position.String()] = true
+			s.Refs = append(s.Refs, Ref{Package: p.PkgPath, File: position.Filename, Line: position.Line, Column: position.Column})
+		}
+	}
+
+	for _, s := range r.Symbols {
+		sort.Slice(s.Refs, func(i, j int) bool {
+			a, b := s.Refs[i], s.Refs[j]
+			if a.File != b.File {
+				return a.File < b.File
+			}
+			if a.Line != b.Line {
+				return a.Line < b.Line
+			}
+			return a.Column < b.Column
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package usage
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/chavacava/gusano/lint"
+	"golang.org/x/tools/go/packages"
+)
+
+func TestParseSymbol(t *testing.T) {
+	yaml := &packages.Package{PkgPath: "gopkg.in/yaml.v3"}
+	client := &packages.Package{PkgPath: "example.com/app/client"}
+	x := &packages.Package{PkgPath: "example.com/x"}
+	x2 := &packages.Package{PkgPath: "example.com/x.v2"}
+	app := &packages.Package{PkgPath: "example.com/app", Imports: map[string]*packages.Package{
+		yaml.PkgPath:   yaml,
+		client.PkgPath: client,
+		x.PkgPath:      x,
+		x2.PkgPath:     x2,
+	}}
+	pkgs := []*packages.Package{app}
+
+	tests := []struct {
+		query, pkgPath, name string
+	}{
+		{"gopkg.in/yaml.v3.Marshal", "gopkg.in/yaml.v3", "Marshal"},
+		{"example.com/app.Run", "example.com/app", "Run"},
+		{"example.com/app/client.Client.Close", "example.com/app/client", "Client.Close"},
+		{"example.com/x.New", "example.com/x", "New"},
+		{"example.com/x.v2.New", "example.com/x.v2", "New"},
+	}
+	for _, test := range tests {
+		pkgPath, name, err := ParseSymbol(pkgs, test.query)
+		if err != nil {
+			t.Errorf("ParseSymbol(%q): unexpected error %v", test.query, err)
+			continue
+		}
+		if pkgPath != test.pkgPath || name != test.name {
+			t.Errorf("ParseSymbol(%q) = %q, %q, want %q, %q", test.query, pkgPath, name, test.pkgPath, test.name)
+		}
+	}
+
+	for _, query := range []string{"fmt.Println", "example.com/app", "example.com/app.", "example.com/app/server.Run", "Marshal"} {
+		if _, _, err := ParseSymbol(pkgs, query); err == nil {
+			t.Errorf("ParseSymbol(%q): expected an error", query)
+		}
+	}
+}
+
+const domain = "github.com/chavacava/gusano/testdata/pkg14/domain"
+
+func loadTestdata(t *testing.T) []*packages.Package {
+	t.Helper()
+	pkgs, err := lint.LoadPackages("../testdata", []string{"./pkg14/..."}, lint.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pkgs
+}
+
+// refs returns the references of the symbol as file:line of package.
+func refs(s *Symbol) []string {
+	result := []string{}
+	for _, ref := range s.Refs {
+		result = append(result, fmt.Sprintf("%s:%d of %s", filepath.Base(ref.File), ref.Line, ref.Package))
+	}
+	return result
+}
+
+func TestAPIUsage(t *testing.T) {
+	report, err := APIUsage(loadTestdata(t), domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string][]string{}
+	for _, s := range report.Symbols {
+		got[s.Name+" "+s.Kind] = refs(s)
+	}
+	want := map[string][]string{
+		"Order type": {
+			"app.go:10 of github.com/chavacava/gusano/testdata/pkg14/app",
+			"app.go:11 of github.com/chavacava/gusano/testdata/pkg14/app",
+			"db.go:10 of github.com/chavacava/gusano/testdata/pkg14/infra/db",
+		},
+		"Order.ID field":      {"app.go:11 of github.com/chavacava/gusano/testdata/pkg14/app"},
+		"Order.String method": {},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("APIUsage(%s) = %v, want %v", domain, got, want)
+	}
+
+	unused := []string{}
+	for _, s := range report.Unused() {
+		unused = append(unused, s.Name)
+	}
+	if !reflect.DeepEqual(unused, []string{"Order.String"}) {
+		t.Errorf("Unused() = %v, want [Order.String]", unused)
+	}
+
+	if _, err := APIUsage(loadTestdata(t), "example.com/unknown"); err == nil {
+		t.Error("APIUsage(example.com/unknown): expected an error")
+	}
+}
+
+func TestWhoUses(t *testing.T) {
+	s, err := WhoUses(loadTestdata(t), domain, "Order.ID")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"app.go:11 of github.com/chavacava/gusano/testdata/pkg14/app",
+		"domain.go:16 of github.com/chavacava/gusano/testdata/pkg14/domain",
+	}
+	if got := refs(s); !reflect.DeepEqual(got, want) {
+		t.Errorf("WhoUses(%s, Order.ID) = %v, want %v", domain, got, want)
+	}
+
+	if _, err := WhoUses(loadTestdata(t), domain, "Order.Total"); err == nil {
+		t.Error("WhoUses(Order.Total): expected an error")
+	}
+}